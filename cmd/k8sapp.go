@@ -5,33 +5,62 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	stdlog "log"
+	"os"
+	"syscall"
 
 	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/termination"
 	"github.com/takama/k8sapp/pkg/service"
 	"github.com/takama/k8sapp/pkg/system"
+	"github.com/takama/k8sapp/pkg/version"
 )
 
 func main() {
 	// Load ENV configuration
 	cfg := new(config.Config)
 	if err := cfg.Load(config.SERVICENAME); err != nil {
-		log.Fatal(err)
+		// The path could be not loaded yet
+		fatal(termination.DefaultPath, system.ExitConfig, err)
 	}
 
 	// Configure service and get router
-	router, logger, err := service.Setup(cfg)
+	router, log, err := service.Setup(cfg)
 	if err != nil {
-		log.Fatal(err)
+		fatal(cfg.TerminationLog, system.ExitConfig, err)
 	}
+	// Unrecovered panics are reported in the termination message
+	defer termination.Recover(log)
 
-	// Listen and serve handlers
-	go router.Listen(fmt.Sprintf("%s:%d", cfg.LocalHost, cfg.LocalPort))
-
-	// Wait signals
+	// Shutdown signals cancel the root context of the service
 	signals := system.NewSignals()
-	if err := signals.Wait(logger, new(system.Handling)); err != nil {
-		logger.Fatal(err)
+	signals.SetDeadline(cfg.ShutdownDeadline)
+	signals.Handle(system.Dump, system.DumpGoroutines(log), syscall.SIGUSR2)
+	signals.Handle(system.Verbose, system.ChangeLogLevel(log, -1), syscall.SIGTTIN)
+	signals.Handle(system.Quiet, system.ChangeLogLevel(log, 1), syscall.SIGTTOU)
+	go signals.Run(context.Background(), logger.Named(log, "signals"), nil)
+
+	// Listen and serve handlers until the shutdown
+	if err := service.Run(signals.Context(), cfg, router, log); err != nil {
+		exit(log, system.ExitCode(err), err)
 	}
+	signals.Stop()
+	// Messages which are logged after shutdown
+	logger.Flush()
+}
+
+// exit writes the termination message and exits with the code of the failure class
+func exit(log logger.Logger, code int, err error) {
+	log.Error(err)
+	termination.Report(log, "fatal: "+err.Error())
+	logger.Exit(code)
+}
+
+// fatal writes the termination message and exits before the logger is configured
+func fatal(path string, code int, err error) {
+	termination.Write(path, termination.Message("fatal: "+err.Error(), version.RELEASE, nil))
+	stdlog.Print(err)
+	os.Exit(code)
 }
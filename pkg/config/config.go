@@ -5,6 +5,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/takama/k8sapp/pkg/logger"
 )
@@ -20,8 +22,59 @@ type Config struct {
 	LocalHost string `split_words:"true"`
 	// Local service port
 	LocalPort int `split_words:"true"`
+	// Time limit of graceful shutdown, it is not limited if zero
+	ShutdownTimeout time.Duration `split_words:"true" default:"20s"`
+	// Time limit of the whole shutdown, the process is terminated
+	// with a goroutine dump when it expires, it is not limited if zero
+	ShutdownDeadline time.Duration `split_words:"true" default:"25s"`
 	// Logging level in logger.Level notation
 	LogLevel logger.Level `split_words:"true"`
+	// Log levels of components, e.g. "http=warn,db=debug"
+	LogLevels logger.Levels `split_words:"true"`
+	// Add file:line and function of the caller into log messages
+	LogCaller bool `split_words:"true"`
+	// Add stack trace into error and fatal log messages
+	LogStacktrace bool `split_words:"true"`
+	// Sampling of repeated log messages is enabled if the interval is set
+	LogSamplingInterval time.Duration `split_words:"true"`
+	// Number of repeated messages which are logged every interval
+	LogSamplingFirst int `split_words:"true"`
+	// Every Nth repeated message is logged after the first ones
+	LogSamplingThereafter int `split_words:"true"`
+	// Output target of messages instead of stdout and stderr: syslog or journald
+	LogSink string `split_words:"true"`
+	// Network of the sink socket: unixgram, unix, udp or tcp
+	LogSinkNetwork string `split_words:"true"`
+	// Address of the sink socket, a default socket of the sink is used if empty
+	LogSinkAddress string `split_words:"true"`
+	// Write messages to stdout and stderr asynchronously
+	LogAsync bool `split_words:"true"`
+	// Size of the queue of asynchronous messages
+	LogAsyncSize int `split_words:"true"`
+	// Drop messages instead of blocking when the queue is full
+	LogAsyncDrop bool `split_words:"true"`
+	// Limit of waiting for queued messages on shutdown
+	LogAsyncFlushTimeout time.Duration `split_words:"true"`
+	// Level of messages from the standard log package and HTTP server
+	LogStdLevel logger.Level `split_words:"true" default:"error"`
+	// Number of recent log messages kept in memory for /debug/logs, disabled if 0
+	LogBufferSize int `split_words:"true"`
+	// Minimal level of log messages kept in memory
+	LogBufferLevel logger.Level `split_words:"true"`
+	// Mask sensitive data (tokens, passwords, emails, card numbers) in log messages
+	LogRedact bool `split_words:"true"`
+	// Additional patterns of field names which values are masked
+	LogRedactFields []string `split_words:"true"`
+	// Additional patterns of values which are masked in log messages
+	LogRedactValues []string `split_words:"true"`
+	// File of the reason of fatal exits for Kubernetes, disabled if empty
+	TerminationLog string `split_words:"true" default:"/dev/termination-log"`
+	// DSN of a Sentry-compatible endpoint of error reports, disabled if empty
+	SentryDSN string `split_words:"true"`
+	// Environment of the service in error reports, e.g. "production"
+	SentryEnvironment string `split_words:"true"`
+	// Token for protected service endpoints, they are disabled if empty
+	AdminToken string `split_words:"true"`
 }
 
 // Load settles ENV variables into Config structure
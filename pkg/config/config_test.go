@@ -1,6 +1,11 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
 
 func TestLoadConfig(t *testing.T) {
 	config := new(Config)
@@ -9,3 +14,35 @@ func TestLoadConfig(t *testing.T) {
 		t.Error("Expected loading of environment vars, got", err)
 	}
 }
+
+func TestLoadLogLevel(t *testing.T) {
+	os.Setenv(SERVICENAME+"_LOG_LEVEL", "Warning")
+	defer os.Unsetenv(SERVICENAME + "_LOG_LEVEL")
+	config := new(Config)
+	if err := config.Load(SERVICENAME); err != nil {
+		t.Error("Expected loading of environment vars, got", err)
+	}
+	if config.LogLevel != logger.LevelWarn {
+		t.Error("Expected log level", logger.LevelWarn, "got", config.LogLevel)
+	}
+	os.Setenv(SERVICENAME+"_LOG_LEVEL", "verbose")
+	if err := new(Config).Load(SERVICENAME); err == nil {
+		t.Error("Expected error for unknown log level")
+	}
+}
+
+func TestLoadLogLevels(t *testing.T) {
+	os.Setenv(SERVICENAME+"_LOG_LEVELS", "http=warn, db=debug")
+	defer os.Unsetenv(SERVICENAME + "_LOG_LEVELS")
+	config := new(Config)
+	if err := config.Load(SERVICENAME); err != nil {
+		t.Error("Expected loading of environment vars, got", err)
+	}
+	if levels := config.LogLevels.String(); levels != "db=debug,http=warn" {
+		t.Error("Expected log levels db=debug,http=warn, got", levels)
+	}
+	os.Setenv(SERVICENAME+"_LOG_LEVELS", "http")
+	if err := new(Config).Load(SERVICENAME); err == nil {
+		t.Error("Expected error for invalid log levels")
+	}
+}
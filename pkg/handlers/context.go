@@ -0,0 +1,147 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// ComponentHTTP is a name of the component logger of requests
+const ComponentHTTP = "http"
+
+// RequestIDHeader contains ID of the request which is logged with its messages
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits request IDs which are received from clients
+const maxRequestIDLength = 128
+
+// RequestLogger stores the logger with fields of the request in the request context
+// of the handler, handlers get it by logger.FromContext. ID of the request is
+// returned in the response header. The response writer is stored as well,
+// it is used for streaming responses which are not supported by bit.Control
+func RequestLogger(log logger.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, id := requestContext(log, r)
+		w.Header().Set(RequestIDHeader, id)
+		ctx = context.WithValue(ctx, writerKey{}, w)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writerKey is a key of the response writer in request contexts
+type writerKey struct{}
+
+// responseWriter returns the response writer stored by RequestLogger,
+// nil is returned if the request is served without it
+func responseWriter(ctx context.Context) http.ResponseWriter {
+	w, _ := ctx.Value(writerKey{}).(http.ResponseWriter)
+	return w
+}
+
+// requestKey is a key of the request ID in request contexts
+type requestKey struct{}
+
+// requestContext returns context of the request with its ID and the logger with
+// fields of the request, the context is returned as is if it has them already
+func requestContext(log logger.Logger, r *http.Request) (context.Context, string) {
+	if id, ok := r.Context().Value(requestKey{}).(string); ok {
+		return r.Context(), id
+	}
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	// Path is not a route pattern, it is not used for grouping of messages
+	fields := logger.Fields{
+		logger.FieldRequestID: id,
+		logger.FieldPath:      r.URL.Path,
+		logger.FieldMethod:    r.Method,
+		logger.FieldHost:      r.Host,
+		logger.FieldScheme:    scheme(r),
+	}
+	if trace := traceID(r.Header); trace != "" {
+		fields[logger.FieldTraceID] = trace
+	}
+	requestLog := logger.WithFields(logger.Named(log, ComponentHTTP), fields)
+	ctx := context.WithValue(r.Context(), requestKey{}, id)
+	return logger.NewContext(ctx, requestLog), id
+}
+
+// shutdownKey is a key of the shutdown channel in request contexts
+type shutdownKey struct{}
+
+// WithShutdown returns context with the channel which is closed when the server
+// shuts down, long-lived responses like log streams are completed then
+func WithShutdown(ctx context.Context, done <-chan struct{}) context.Context {
+	return context.WithValue(ctx, shutdownKey{}, done)
+}
+
+// shutdown returns the shutdown channel of the request context,
+// the nil channel is returned if the server does not provide it
+func shutdown(ctx context.Context) <-chan struct{} {
+	done, _ := ctx.Value(shutdownKey{}).(<-chan struct{})
+	return done
+}
+
+// scheme returns scheme of the request, it could be terminated by a proxy
+func scheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		return proto
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// traceID returns trace ID of W3C Trace Context, B3 or Google Cloud trace headers
+func traceID(header http.Header) string {
+	if parent := header.Get("Traceparent"); parent != "" {
+		// version-traceid-parentid-flags
+		if parts := strings.Split(parent, "-"); len(parts) == 4 && len(parts[1]) == 32 {
+			return parts[1]
+		}
+	}
+	if trace := header.Get("X-B3-TraceId"); trace != "" && len(trace) <= 32 {
+		return trace
+	}
+	if trace := header.Get("X-Cloud-Trace-Context"); trace != "" {
+		// TRACE_ID/SPAN_ID;o=TRACE_TRUE
+		if i := strings.IndexAny(trace, "/;"); i >= 0 {
+			trace = trace[:i]
+		}
+		if len(trace) <= 32 {
+			return trace
+		}
+	}
+	return ""
+}
+
+// validRequestID accepts IDs of limited length without spaces and control characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
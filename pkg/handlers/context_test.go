@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestRequestLogger(t *testing.T) {
+	log := loggertest.New()
+	h := New(log, new(config.Config))
+	handler := RequestLogger(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Base(func(c bit.Control) {
+			logger.FromContext(c.Request().Context()).Info("request message")
+			c.Code(http.StatusOK)
+			c.Body("ok")
+		})(bit.NewControl(w, r))
+	}))
+
+	req := httptest.NewRequest("GET", "/info", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	trw := httptest.NewRecorder()
+	handler.ServeHTTP(trw, req)
+
+	if id := trw.Header().Get(RequestIDHeader); id != "request-1" {
+		t.Error("Expected request ID in response header, got", id)
+	}
+	entries := log.Entries()
+	if len(entries) != 1 {
+		t.Fatal("Expected 1 message, got", len(entries))
+	}
+	want := logger.Fields{
+		logger.FieldRequestID: "request-1",
+		logger.FieldPath:      "/info",
+		logger.FieldMethod:    "GET",
+		logger.FieldTraceID:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		logger.FieldHost:      "example.com",
+		logger.FieldScheme:    "http",
+		logger.FieldComponent: ComponentHTTP,
+	}
+	for key, value := range want {
+		if entries[0].Fields[key] != value {
+			t.Errorf("invalid %s field:\ngot:  %v\nwant: %v", key, entries[0].Fields[key], value)
+		}
+	}
+
+	// Invalid request ID is replaced by a generated one
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	trw = httptest.NewRecorder()
+	handler.ServeHTTP(trw, req)
+	if id := trw.Header().Get(RequestIDHeader); len(id) != 32 || strings.Contains(id, " ") {
+		t.Error("Expected generated request ID, got", id)
+	}
+	if _, ok := log.Entries()[1].Fields[logger.FieldTraceID]; ok {
+		t.Error("Expected message without trace ID")
+	}
+}
+
+func TestBaseRequestLogger(t *testing.T) {
+	log := loggertest.New()
+	h := New(log, new(config.Config))
+	handle := h.Base(func(c bit.Control) {
+		logger.FromContext(c.Request().Context()).Info("request message")
+		c.Code(http.StatusOK)
+		c.Body("ok")
+	})
+
+	// Router is served without RequestLogger
+	req := httptest.NewRequest("GET", "/info", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	handle(bit.NewControl(httptest.NewRecorder(), req))
+	entries := log.Entries()
+	if len(entries) != 1 {
+		t.Fatal("Expected 1 message, got", len(entries))
+	}
+	if id := entries[0].Fields[logger.FieldRequestID]; id != "request-1" {
+		t.Error("Expected request ID in message, got", id)
+	}
+	if path := entries[0].Fields[logger.FieldPath]; path != "/info" {
+		t.Error("Expected path in message, got", path)
+	}
+
+	// Request logger of RequestLogger is kept
+	handler := RequestLogger(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle(bit.NewControl(w, r))
+	}))
+	trw := httptest.NewRecorder()
+	handler.ServeHTTP(trw, httptest.NewRequest("GET", "/", nil))
+	entries = log.Entries()
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 messages, got", len(entries))
+	}
+	if id := entries[1].Fields[logger.FieldRequestID]; id != trw.Header().Get(RequestIDHeader) {
+		t.Error("Expected request ID", trw.Header().Get(RequestIDHeader), "got", id)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	data := map[string]string{
+		"X-B3-TraceId":          "463ac35c9f6413ad",
+		"X-Cloud-Trace-Context": "105445aa7843bc8bf206b120001000/1;o=1",
+	}
+	want := map[string]string{
+		"X-B3-TraceId":          "463ac35c9f6413ad",
+		"X-Cloud-Trace-Context": "105445aa7843bc8bf206b120001000",
+	}
+	for name, value := range data {
+		header := http.Header{}
+		header.Set(name, value)
+		if trace := traceID(header); trace != want[name] {
+			t.Errorf("invalid trace ID of %s:\ngot:  %v\nwant: %v", name, trace, want[name])
+		}
+	}
+	if trace := traceID(http.Header{}); trace != "" {
+		t.Error("Expected empty trace ID, got", trace)
+	}
+}
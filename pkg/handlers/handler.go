@@ -7,6 +7,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/takama/bit"
@@ -14,6 +15,7 @@ import (
 	// "github.com/takama/k8sapp/pkg/router/httprouter"
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/ring"
 	"github.com/takama/k8sapp/pkg/version"
 )
 
@@ -23,6 +25,9 @@ type Handler struct {
 	config      *config.Config
 	maintenance bool
 	stats       *stats
+	reverter    levelReverter
+	logs        *ring.Buffer
+	dropped     []func() uint64
 }
 
 type stats struct {
@@ -50,12 +55,57 @@ func New(logger logger.Logger, config *config.Config) *Handler {
 func (h *Handler) Base(handle func(bit.Control)) func(bit.Control) {
 	return func(c bit.Control) {
 		timer := time.Now()
-		handle(c)
+		c = h.control(c)
+		h.handle(c, handle)
 		h.countDuration(timer)
 		h.collectCodes(c)
 	}
 }
 
+// control returns the control with the request logger in the request context,
+// it is stored here if the router is served without RequestLogger
+func (h *Handler) control(c bit.Control) bit.Control {
+	r := c.Request()
+	ctx, _ := requestContext(h.logger, r)
+	if ctx == r.Context() {
+		return c
+	}
+	return &requestControl{Control: c, request: r.WithContext(ctx)}
+}
+
+// requestControl replaces the request of the control
+type requestControl struct {
+	bit.Control
+	request *http.Request
+}
+
+// Request returns the request with the request logger in its context
+func (c *requestControl) Request() *http.Request {
+	return c.request
+}
+
+// handle recovers panics of the handler, they are logged with logger.FieldPanic
+// to be reported as panics and the request is completed with status 500
+func (h *Handler) handle(c bit.Control, handle func(bit.Control)) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		// The server aborts the response silently
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		log := logger.WithFields(
+			logger.FromContext(c.Request().Context()), logger.Fields{logger.FieldPanic: fmt.Sprint(p)},
+		)
+		log.Errorf("panic: %v\n%s", p, debug.Stack())
+		c.Code(http.StatusInternalServerError)
+		c.Body(http.StatusText(http.StatusInternalServerError))
+	}()
+	handle(c)
+}
+
 // Root handler shows version
 func (h *Handler) Root(c bit.Control) {
 	c.Code(http.StatusOK)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/takama/bit"
@@ -11,12 +12,12 @@ import (
 	// "github.com/takama/k8sapp/pkg/router/httprouter"
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/logger"
-	"github.com/takama/k8sapp/pkg/logger/standard"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 	"github.com/takama/k8sapp/pkg/version"
 )
 
 func TestRoot(t *testing.T) {
-	h := New(standard.New(&logger.Config{}), new(config.Config))
+	h := New(loggertest.New(), new(config.Config))
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.Base(h.Root)(bit.NewControl(w, r))
 	})
@@ -42,7 +43,7 @@ func testHandler(t *testing.T, handler http.HandlerFunc, code int, body string)
 }
 
 func TestCollectCodes(t *testing.T) {
-	h := New(standard.New(&logger.Config{}), new(config.Config))
+	h := New(loggertest.New(), new(config.Config))
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.Base(func(c bit.Control) {
 			c.Code(http.StatusBadGateway)
@@ -59,3 +60,24 @@ func TestCollectCodes(t *testing.T) {
 	})
 	testHandler(t, handler, http.StatusNotFound, http.StatusText(http.StatusNotFound))
 }
+
+func TestPanic(t *testing.T) {
+	log := loggertest.New()
+	h := New(log, new(config.Config))
+	handler := RequestLogger(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Base(func(c bit.Control) {
+			panic("unexpected")
+		})(bit.NewControl(w, r))
+	}))
+	testHandler(t, handler.ServeHTTP, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	entries := log.Filter(logger.LevelError)
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Message, "panic: unexpected\n") {
+		t.Fatal("Expected logged panic, got", entries)
+	}
+	if entries[0].Fields[logger.FieldPanic] != "unexpected" || entries[0].Fields[logger.FieldRequestID] == nil {
+		t.Error("Expected panic and request fields, got", entries[0].Fields)
+	}
+	if h.stats.requests.Codes.C5xx != 1 {
+		t.Error("Expected counted status code 500, got", h.stats.requests.Codes.C5xx)
+	}
+}
@@ -8,12 +8,11 @@ import (
 	// Alternative of the Bit router with the same Router interface
 	// "github.com/takama/k8sapp/pkg/router/httprouter"
 	"github.com/takama/k8sapp/pkg/config"
-	"github.com/takama/k8sapp/pkg/logger"
-	"github.com/takama/k8sapp/pkg/logger/standard"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 )
 
 func TestHealth(t *testing.T) {
-	h := New(standard.New(&logger.Config{}), new(config.Config))
+	h := New(loggertest.New(), new(config.Config))
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.Base(h.Health)(bit.NewControl(w, r))
 	})
@@ -27,6 +27,7 @@ type Status struct {
 	Runtime  Runtime  `json:"runtime"`
 	State    State    `json:"state"`
 	Requests Requests `json:"requests"`
+	Logs     Logs     `json:"logs"`
 }
 
 // Runtime defines runtime part of service information
@@ -42,6 +43,12 @@ type State struct {
 	Uptime      string `json:"uptime"`
 }
 
+// Logs contains statistics of log messages
+type Logs struct {
+	// Dropped messages of asynchronous outputs
+	Dropped uint64 `json:"dropped"`
+}
+
 // Requests collects responses statistics
 type Requests struct {
 	Duration Duration `json:"duration"`
@@ -94,5 +101,21 @@ func (h *Handler) Info(c bit.Control) {
 				C5xx: h.stats.requests.Codes.C5xx,
 			},
 		},
+		Logs: Logs{
+			Dropped: h.droppedLogs(),
+		},
 	})
 }
+
+// AddDroppedLogs adds counter of dropped log messages, e.g. of asynchronous outputs
+func (h *Handler) AddDroppedLogs(counter func() uint64) {
+	h.dropped = append(h.dropped, counter)
+}
+
+func (h *Handler) droppedLogs() uint64 {
+	var dropped uint64
+	for _, counter := range h.dropped {
+		dropped += counter()
+	}
+	return dropped
+}
@@ -10,13 +10,14 @@ import (
 	// Alternative of the Bit router with the same Router interface
 	// "github.com/takama/k8sapp/pkg/router/httprouter"
 	"github.com/takama/k8sapp/pkg/config"
-	"github.com/takama/k8sapp/pkg/logger"
-	"github.com/takama/k8sapp/pkg/logger/standard"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 	"github.com/takama/k8sapp/pkg/version"
 )
 
 func TestInfo(t *testing.T) {
-	h := New(standard.New(&logger.Config{}), new(config.Config))
+	h := New(loggertest.New(), new(config.Config))
+	h.AddDroppedLogs(func() uint64 { return 1 })
+	h.AddDroppedLogs(func() uint64 { return 2 })
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.Base(h.Info)(bit.NewControl(w, r))
 	})
@@ -48,4 +49,7 @@ func TestInfo(t *testing.T) {
 	if s.Repo != version.REPO {
 		t.Error("Expected repository:", version.REPO, "got", s.Repo)
 	}
+	if s.Logs.Dropped != 3 {
+		t.Error("Expected dropped log messages:", 3, "got", s.Logs.Dropped)
+	}
 }
@@ -0,0 +1,155 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// LogLevel contains the log level of the service
+type LogLevel struct {
+	// Level contains the current or requested log level
+	Level logger.Level `json:"level"`
+	// Duration of the requested log level, e.g. "5m",
+	// the previous level is restored when it expires
+	Duration string `json:"duration,omitempty"`
+	// Revert shows time when the previous level will be restored
+	Revert *time.Time `json:"revert,omitempty"`
+}
+
+// logLevelRequest contains the requested log level, the level is required
+type logLevelRequest struct {
+	Level    *logger.Level `json:"level"`
+	Duration string        `json:"duration"`
+}
+
+// levelReverter restores the previous log level by timer
+type levelReverter struct {
+	mutex sync.Mutex
+	timer *time.Timer
+	level logger.Level
+	at    time.Time
+}
+
+// LogLevel returns the current log level of the service
+func (h *Handler) LogLevel(c bit.Control) {
+	leveler, ok := h.logger.(logger.Leveler)
+	if !ok {
+		c.Code(http.StatusNotImplemented)
+		c.Body("Log level can not be changed for this logger")
+		return
+	}
+	c.Code(http.StatusOK)
+	c.Body(h.logLevel(leveler))
+}
+
+// SetLogLevel changes the log level of the service,
+// the previous level is restored after the duration if it is specified
+func (h *Handler) SetLogLevel(c bit.Control) {
+	if !h.authorized(c) {
+		return
+	}
+	leveler, ok := h.logger.(logger.Leveler)
+	if !ok {
+		c.Code(http.StatusNotImplemented)
+		c.Body("Log level can not be changed for this logger")
+		return
+	}
+	var request logLevelRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&request); err != nil {
+		c.Code(http.StatusBadRequest)
+		c.Body("Invalid log level request: " + err.Error())
+		return
+	}
+	if request.Level == nil {
+		c.Code(http.StatusBadRequest)
+		c.Body("Log level is required")
+		return
+	}
+	var duration time.Duration
+	if request.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(request.Duration)
+		if err != nil || duration <= 0 {
+			c.Code(http.StatusBadRequest)
+			c.Body("Invalid log level duration: " + request.Duration)
+			return
+		}
+	}
+	h.changeLogLevel(logger.FromContext(c.Request().Context()), leveler, *request.Level, duration)
+	c.Code(http.StatusOK)
+	c.Body(h.logLevel(leveler))
+}
+
+func (h *Handler) logLevel(leveler logger.Leveler) LogLevel {
+	h.reverter.mutex.Lock()
+	defer h.reverter.mutex.Unlock()
+	status := LogLevel{Level: leveler.Level()}
+	if h.reverter.timer != nil {
+		at := h.reverter.at
+		status.Revert = &at
+	}
+	return status
+}
+
+func (h *Handler) changeLogLevel(
+	log logger.Logger, leveler logger.Leveler, level logger.Level, duration time.Duration,
+) {
+	h.reverter.mutex.Lock()
+	defer h.reverter.mutex.Unlock()
+	if h.reverter.timer != nil {
+		// Keep the level which was used before the first temporary change
+		h.reverter.timer.Stop()
+		h.reverter.timer = nil
+	} else {
+		h.reverter.level = leveler.Level()
+	}
+	// Message is logged before the change to be visible in case of a higher level
+	log.Warnf("Log level changed to %s", level)
+	leveler.SetLevel(level)
+	if duration > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			h.reverter.mutex.Lock()
+			defer h.reverter.mutex.Unlock()
+			// The timer could be replaced while waiting for the lock
+			if h.reverter.timer != timer {
+				return
+			}
+			h.reverter.timer = nil
+			h.logger.Warnf("Log level restored to %s", h.reverter.level)
+			leveler.SetLevel(h.reverter.level)
+		})
+		h.reverter.timer = timer
+		h.reverter.at = time.Now().Add(duration)
+	}
+}
+
+// authorized checks the bearer token of the protected endpoints
+func (h *Handler) authorized(c bit.Control) bool {
+	if h.config.AdminToken == "" {
+		c.Code(http.StatusForbidden)
+		c.Body("Endpoint is disabled, admin token is not configured")
+		return false
+	}
+	auth := c.Request().Header.Get("Authorization")
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AdminToken)) != 1 {
+		c.Code(http.StatusUnauthorized)
+		c.Body(http.StatusText(http.StatusUnauthorized))
+		return false
+	}
+	return true
+}
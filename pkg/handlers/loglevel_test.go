@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+const testToken = "secret"
+
+func requestLogLevel(t *testing.T, h *Handler, handle func(bit.Control), method, token, body string) (int, LogLevel) {
+	req, err := http.NewRequest(method, "/loglevel", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	trw := httptest.NewRecorder()
+	RequestLogger(h.logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle(bit.NewControl(w, r))
+	})).ServeHTTP(trw, req)
+	var status LogLevel
+	if trw.Code == http.StatusOK {
+		if err := json.Unmarshal(trw.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return trw.Code, status
+}
+
+func TestLogLevel(t *testing.T) {
+	log := loggertest.New()
+	log.SetLevel(logger.LevelInfo)
+	h := New(log, new(config.Config))
+	code, status := requestLogLevel(t, h, h.Base(h.LogLevel), "GET", "", "")
+	if code != http.StatusOK {
+		t.Error("Expected status:", http.StatusOK, "got", code)
+	}
+	if status.Level != logger.LevelInfo {
+		t.Error("Expected log level:", logger.LevelInfo, "got", status.Level)
+	}
+}
+
+func TestSetLogLevelProtection(t *testing.T) {
+	h := New(loggertest.New(), new(config.Config))
+	code, _ := requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", testToken, `{"level":3}`)
+	if code != http.StatusForbidden {
+		t.Error("Expected status:", http.StatusForbidden, "got", code)
+	}
+	h = New(loggertest.New(), &config.Config{AdminToken: testToken})
+	code, _ = requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", "", `{"level":3}`)
+	if code != http.StatusUnauthorized {
+		t.Error("Expected status:", http.StatusUnauthorized, "got", code)
+	}
+	code, _ = requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", "wrong", `{"level":3}`)
+	if code != http.StatusUnauthorized {
+		t.Error("Expected status:", http.StatusUnauthorized, "got", code)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	log := loggertest.New()
+	log.SetLevel(logger.LevelInfo)
+	h := New(log, &config.Config{AdminToken: testToken})
+	code, status := requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", testToken, `{"level":3}`)
+	if code != http.StatusOK {
+		t.Error("Expected status:", http.StatusOK, "got", code)
+	}
+	if status.Level != logger.LevelError || status.Revert != nil {
+		t.Error("Expected permanent log level:", logger.LevelError, "got", status.Level, status.Revert)
+	}
+	if level := log.Level(); level != logger.LevelError {
+		t.Error("Expected log level:", logger.LevelError, "got", level)
+	}
+	if !log.Contains(logger.LevelWarn, "Log level changed to error") {
+		t.Error("Expected message about changed log level, got", log.Entries())
+	}
+	for _, body := range []string{"level", "{}", `{"duration":"5m"}`, `{"level":null}`, `{"level":"verbose"}`, `{"level":"debug","duration":"5"}`, `{"level":0,"duration":"-1s"}`} {
+		code, _ = requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", testToken, body)
+		if code != http.StatusBadRequest {
+			t.Error("Expected status:", http.StatusBadRequest, "got", code, "for", body)
+		}
+	}
+	if level := log.Level(); level != logger.LevelError {
+		t.Error("Expected unchanged log level after invalid requests:", logger.LevelError, "got", level)
+	}
+}
+
+func TestSetLogLevelRevert(t *testing.T) {
+	log := loggertest.New()
+	log.SetLevel(logger.LevelInfo)
+	h := New(log, &config.Config{AdminToken: testToken})
+	code, status := requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", testToken, `{"level":"debug","duration":"1h"}`)
+	if code != http.StatusOK {
+		t.Error("Expected status:", http.StatusOK, "got", code)
+	}
+	if status.Level != logger.LevelDebug || status.Revert == nil {
+		t.Error("Expected temporary log level:", logger.LevelDebug, "got", status.Level, status.Revert)
+	}
+	// Repeated temporary change must restore the original level
+	requestLogLevel(t, h, h.Base(h.SetLogLevel), "PUT", testToken, `{"level":2,"duration":"10ms"}`)
+	for i := 0; i < 100 && log.Level() != logger.LevelInfo; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if level := log.Level(); level != logger.LevelInfo {
+		t.Error("Expected restored log level:", logger.LevelInfo, "got", level)
+	}
+	if !log.Contains(logger.LevelWarn, "Log level restored to info") {
+		t.Error("Expected message about restored log level, got", log.Entries())
+	}
+	_, status = requestLogLevel(t, h, h.Base(h.LogLevel), "GET", "", "")
+	if status.Revert != nil {
+		t.Error("Expected empty revert time, got", status.Revert)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/ring"
+)
+
+// Streaming of log messages
+const (
+	// Size of the queue of streamed messages for every client
+	logsStreamQueue = 256
+	// Interval of comments which keep the stream alive through proxies
+	logsKeepAlive = 15 * time.Second
+)
+
+// SetLogBuffer defines buffer of recent log messages for the Logs handler
+func (h *Handler) SetLogBuffer(buffer *ring.Buffer) {
+	h.logs = buffer
+}
+
+// Logs returns recent log messages of the service as JSON or text,
+// messages are streamed as Server-Sent Events if follow is requested.
+// Query parameters: level, limit, format (json, text), follow (true)
+func (h *Handler) Logs(c bit.Control) {
+	if !h.authorized(c) {
+		return
+	}
+	if h.logs == nil {
+		c.Code(http.StatusNotImplemented)
+		c.Body("Log buffer is disabled")
+		return
+	}
+	level := logger.LevelDebug
+	if name := c.Query("level"); name != "" {
+		var err error
+		if level, err = logger.ParseLevel(name); err != nil {
+			c.Code(http.StatusBadRequest)
+			c.Body(err.Error())
+			return
+		}
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			c.Code(http.StatusBadRequest)
+			c.Body("Invalid limit of log messages: " + value)
+			return
+		}
+	}
+	follow := c.Query("follow") == "true" ||
+		strings.Contains(c.Request().Header.Get("Accept"), "text/event-stream")
+	if follow {
+		h.streamLogs(c, level, limit)
+		return
+	}
+	entries := lastEntries(h.logs.Entries(level), limit)
+	c.Code(http.StatusOK)
+	if c.Query("format") == "text" {
+		var text strings.Builder
+		for _, entry := range entries {
+			text.WriteString(entry.String() + "\n")
+		}
+		c.Body(text.String())
+		return
+	}
+	if entries == nil {
+		entries = []ring.Entry{}
+	}
+	c.Body(entries)
+}
+
+// streamLogs sends recent and new log messages as Server-Sent Events,
+// they are written to the response writer which is stored by RequestLogger
+func (h *Handler) streamLogs(c bit.Control, level logger.Level, limit int) {
+	w := responseWriter(c.Request().Context())
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		c.Code(http.StatusNotImplemented)
+		c.Body("Streaming requires the response writer of handlers.RequestLogger")
+		return
+	}
+	entries, messages, cancel := h.logs.Follow(level, logsStreamQueue)
+	defer cancel()
+
+	c.Code(http.StatusOK)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, entry := range lastEntries(entries, limit) {
+		if err := writeEvent(w, entry); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(logsKeepAlive)
+	defer keepAlive.Stop()
+	done := c.Request().Context().Done()
+	closing := shutdown(c.Request().Context())
+	for {
+		select {
+		case <-done:
+			return
+		case <-closing:
+			return
+		case entry := <-messages:
+			if entry.Level < level {
+				continue
+			}
+			if err := writeEvent(w, entry); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, entry ring.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+func lastEntries(entries []ring.Entry, limit int) []ring.Entry {
+	if limit > 0 && len(entries) > limit {
+		return entries[len(entries)-limit:]
+	}
+	return entries
+}
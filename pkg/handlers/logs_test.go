@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+	"github.com/takama/k8sapp/pkg/logger/ring"
+)
+
+func requestLogs(t *testing.T, h *Handler, token, query string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", "/debug/logs?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	trw := httptest.NewRecorder()
+	h.Base(h.Logs)(bit.NewControl(trw, req))
+	return trw
+}
+
+func TestLogs(t *testing.T) {
+	buffer := ring.New(loggertest.New(), 10, logger.LevelDebug)
+	h := New(buffer, &config.Config{AdminToken: testToken})
+	if trw := requestLogs(t, h, testToken, ""); trw.Code != http.StatusNotImplemented {
+		t.Error("Expected status:", http.StatusNotImplemented, "got", trw.Code)
+	}
+	h.SetLogBuffer(buffer)
+	buffer.Info("info message")
+	buffer.Warn("warn message")
+	buffer.Error("error message")
+
+	if trw := requestLogs(t, h, "", ""); trw.Code != http.StatusUnauthorized {
+		t.Error("Expected status:", http.StatusUnauthorized, "got", trw.Code)
+	}
+	trw := requestLogs(t, h, testToken, "level=warn&limit=1")
+	if trw.Code != http.StatusOK {
+		t.Fatal("Expected status:", http.StatusOK, "got", trw.Code)
+	}
+	var entries []ring.Entry
+	if err := json.Unmarshal(trw.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Message != "error message" || entries[0].Level != logger.LevelError {
+		t.Error("Expected the last error message, got", entries)
+	}
+
+	trw = requestLogs(t, h, testToken, "format=text&level=warning")
+	lines := strings.Split(strings.TrimSpace(trw.Body.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "[warn] warn message") {
+		t.Error("Expected warn and error messages in text, got", trw.Body.String())
+	}
+
+	// Streams require the response writer of RequestLogger
+	if trw := requestLogs(t, h, testToken, "follow=true"); trw.Code != http.StatusNotImplemented {
+		t.Error("Expected status:", http.StatusNotImplemented, "got", trw.Code)
+	}
+
+	for _, query := range []string{"level=verbose", "limit=-1"} {
+		if trw := requestLogs(t, h, testToken, query); trw.Code != http.StatusBadRequest {
+			t.Error("Expected status:", http.StatusBadRequest, "for", query, "got", trw.Code)
+		}
+	}
+}
+
+func TestLogsStream(t *testing.T) {
+	buffer := ring.New(loggertest.New(), 10, logger.LevelDebug)
+	h := New(buffer, &config.Config{AdminToken: testToken})
+	h.SetLogBuffer(buffer)
+	buffer.Info("old message")
+	server := httptest.NewServer(RequestLogger(buffer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Base(h.Logs)(bit.NewControl(w, r))
+	})))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/debug/logs?follow=true&level=info", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Error("Expected event stream, got", ct)
+	}
+	events := make(chan ring.Entry)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var entry ring.Entry
+			if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+				if json.Unmarshal([]byte(data), &entry) == nil {
+					events <- entry
+				}
+			}
+		}
+		close(events)
+	}()
+	next := func() ring.Entry {
+		select {
+		case entry := <-events:
+			return entry
+		case <-time.After(time.Second):
+			t.Fatal("Expected streamed message")
+		}
+		return ring.Entry{}
+	}
+	if entry := next(); entry.Message != "old message" {
+		t.Error("Expected kept message, got", entry.Message)
+	}
+	buffer.Debug("filtered message")
+	buffer.Warn("new message")
+	if entry := next(); entry.Message != "new message" {
+		t.Error("Expected new message, got", entry.Message)
+	}
+}
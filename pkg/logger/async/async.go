@@ -0,0 +1,180 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package async
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy defines behavior of writes when the queue is full
+type Policy int
+
+const (
+	// Block waits for a free place in the queue
+	Block Policy = iota
+	// Drop discards messages and counts them
+	Drop
+)
+
+func (p Policy) String() string {
+	if p == Drop {
+		return "drop"
+	}
+	return "block"
+}
+
+// Defaults of the writer
+const (
+	DefaultSize         = 1024
+	DefaultFlushTimeout = 5 * time.Second
+)
+
+// ErrFlushTimeout is returned if queued messages were not written in time
+var ErrFlushTimeout = errors.New("timeout of flush of asynchronous log writer")
+
+// Config contains settings of the asynchronous writer
+type Config struct {
+	// Size of the queue of messages
+	Size int
+	// Policy of writes when the queue is full
+	Policy Policy
+	// FlushTimeout limits waiting of Flush
+	FlushTimeout time.Duration
+}
+
+// item is a queued message or a flush marker
+type item struct {
+	data []byte
+	done chan struct{}
+}
+
+// Writer queues messages and writes them in background,
+// adapters use it as Out and Err of logger.Config
+type Writer struct {
+	out     io.Writer
+	config  Config
+	queue   chan item
+	dropped uint64
+
+	// mutex protects closed queue from writes
+	mutex  sync.RWMutex
+	closed bool
+	stop   chan struct{}
+}
+
+// New returns asynchronous writer into the output
+func New(out io.Writer, config Config) *Writer {
+	if config.Size <= 0 {
+		config.Size = DefaultSize
+	}
+	if config.FlushTimeout <= 0 {
+		config.FlushTimeout = DefaultFlushTimeout
+	}
+	w := &Writer{
+		out:    out,
+		config: config,
+		queue:  make(chan item, config.Size),
+		stop:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues copy of the data, it is written directly if the writer is closed
+func (w *Writer) Write(data []byte) (int, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return w.out.Write(data)
+	}
+	// Adapters reuse their buffers after the write
+	msg := item{data: append([]byte(nil), data...)}
+	if w.config.Policy == Drop {
+		select {
+		case w.queue <- msg:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+		return len(data), nil
+	}
+	w.queue <- msg
+	return len(data), nil
+}
+
+// Dropped returns a total number of dropped messages
+func (w *Writer) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until queued messages are written or flush timeout expires
+func (w *Writer) Flush() error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return nil
+	}
+	timer := time.NewTimer(w.config.FlushTimeout)
+	defer timer.Stop()
+	done := make(chan struct{})
+	select {
+	case w.queue <- item{done: done}:
+	case <-timer.C:
+		return ErrFlushTimeout
+	}
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return ErrFlushTimeout
+	}
+}
+
+// Close flushes queued messages and stops background writing,
+// next messages are written directly
+func (w *Writer) Close() error {
+	err := w.Flush()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	return err
+}
+
+func (w *Writer) run() {
+	for {
+		select {
+		case msg := <-w.queue:
+			w.write(msg)
+		case <-w.stop:
+			// Messages which were queued before closing
+			for {
+				select {
+				case msg := <-w.queue:
+					w.write(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) write(msg item) {
+	if msg.done != nil {
+		close(msg.done)
+		return
+	}
+	if _, err := w.out.Write(msg.data); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
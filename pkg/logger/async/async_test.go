@@ -0,0 +1,105 @@
+package async
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter collects messages and blocks writes until it is released
+type slowWriter struct {
+	release chan struct{}
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *slowWriter) Write(data []byte) (int, error) {
+	<-w.release
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.Write(data)
+}
+
+func (w *slowWriter) String() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.String()
+}
+
+func TestWriter(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	close(out.release)
+	w := New(out, Config{})
+	data := []byte("first message\n")
+	w.Write(data)
+	// The data is copied before the write returns
+	copy(data, "xxxxx")
+	fmt.Fprint(w, "second message\n")
+	if err := w.Flush(); err != nil {
+		t.Fatal("Expected flush of messages, got", err)
+	}
+	if got := out.String(); got != "first message\nsecond message\n" {
+		t.Errorf("invalid output:\ngot:  %q\nwant: %q", got, "first message\nsecond message\n")
+	}
+	if err := w.Close(); err != nil {
+		t.Error("Expected close of the writer, got", err)
+	}
+	fmt.Fprint(w, "direct message\n")
+	if got := out.String(); !strings.HasSuffix(got, "direct message\n") {
+		t.Error("Expected direct write after close, got", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Error("Expected no errors of closed writer, got", err)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	w := New(out, Config{Size: 2, Policy: Drop, FlushTimeout: 10 * time.Millisecond})
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(w, "message %d\n", i)
+	}
+	// One message is taken by the blocked writer, two are queued
+	if dropped := w.Dropped(); dropped < 7 || dropped > 8 {
+		t.Error("Expected 7 or 8 dropped messages, got", dropped)
+	}
+	if err := w.Flush(); err != ErrFlushTimeout {
+		t.Error("Expected", ErrFlushTimeout, "got", err)
+	}
+	close(out.release)
+	w.Close()
+	if count := strings.Count(out.String(), "message"); uint64(count)+w.Dropped() != 10 {
+		t.Error("Expected written and dropped messages, got", count, w.Dropped())
+	}
+}
+
+func TestBlock(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	w := New(out, Config{Size: 1, Policy: Block})
+	written := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "message %d\n", i)
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Expected blocked writes")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(out.release)
+	<-written
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if count := strings.Count(out.String(), "message"); count != 5 || w.Dropped() != 0 {
+		t.Error("Expected all of 5 messages, got", count, "dropped", w.Dropped())
+	}
+	if Block.String() != "block" || Drop.String() != "drop" {
+		t.Error("invalid names of policies:", Block, Drop)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import "sync/atomic"
+
+// Leveler defines the interface for a logger with a level changeable at runtime
+type Leveler interface {
+	// Level returns the current log level
+	Level() Level
+	// SetLevel changes the log level
+	SetLevel(level Level)
+}
+
+// AtomicLevel contains a log level which can be safely read and changed
+// from different goroutines
+type AtomicLevel struct {
+	level int32
+}
+
+// NewAtomicLevel returns a new AtomicLevel with the specified level
+func NewAtomicLevel(level Level) *AtomicLevel {
+	return &AtomicLevel{level: int32(level)}
+}
+
+// Level returns the current log level
+func (a *AtomicLevel) Level() Level {
+	return Level(atomic.LoadInt32(&a.level))
+}
+
+// SetLevel changes the log level
+func (a *AtomicLevel) SetLevel(level Level) {
+	atomic.StoreInt32(&a.level, int32(level))
+}
+
+// Enabled checks if messages with the specified level should be logged
+func (a *AtomicLevel) Enabled(level Level) bool {
+	return level >= a.Level()
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Fields which contain caller and stack information of messages
+const (
+	FieldCaller   = "caller"
+	FieldFunction = "func"
+	FieldStack    = "stack"
+)
+
+// Packages which are skipped to find the caller of a logger,
+// they contain adapters, wrappers and logging libraries
+var loggingPackages = []string{
+	"github.com/takama/k8sapp/pkg/logger",
+	"github.com/sirupsen/logrus",
+	"github.com/rs/xlog",
+	"log",
+}
+
+// maxStackDepth limits number of frames in stack traces
+const maxStackDepth = 64
+
+// Caller describes the source of a log message
+type Caller struct {
+	PC       uintptr
+	File     string
+	Line     int
+	Function string
+}
+
+// String returns short file name and line of the caller, e.g. "handlers/info.go:17"
+func (c Caller) String() string {
+	return filepath.Join(filepath.Base(filepath.Dir(c.File)), filepath.Base(c.File)) +
+		":" + strconv.Itoa(c.Line)
+}
+
+// FuncName returns function name of the caller without path of the package
+func (c Caller) FuncName() string {
+	return c.Function[strings.LastIndex(c.Function, "/")+1:]
+}
+
+// FindCaller returns the first caller outside of logging packages,
+// skip defines a number of additional frames between the caller and the logger,
+// e.g. helper functions which log messages on behalf of their callers
+func FindCaller(skip int) (Caller, bool) {
+	frames := callers()
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame) {
+			if skip <= 0 {
+				return Caller{
+					PC:       frame.PC,
+					File:     frame.File,
+					Line:     frame.Line,
+					Function: frame.Function,
+				}, true
+			}
+			skip--
+		}
+		if !more {
+			return Caller{}, false
+		}
+	}
+}
+
+// Stack returns stack trace which begins from the caller of the logger
+func Stack(skip int) string {
+	var b strings.Builder
+	frames := callers()
+	found := false
+	for {
+		frame, more := frames.Next()
+		if !found && !isLoggingFrame(frame) {
+			if skip <= 0 {
+				found = true
+			}
+			skip--
+		}
+		if found {
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte('\n')
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// Annotations returns fields with caller and stack of a message
+// if they are enabled, stack trace is added into error and fatal messages only
+func Annotations(level Level, caller, stacktrace bool, skip int) Fields {
+	fields := make(Fields, 3)
+	if caller {
+		if c, ok := FindCaller(skip); ok {
+			fields[FieldCaller] = c.String()
+			fields[FieldFunction] = c.FuncName()
+		}
+	}
+	if stacktrace && level >= LevelError {
+		fields[FieldStack] = Stack(skip)
+	}
+	return fields
+}
+
+func callers() *runtime.Frames {
+	var pcs [maxStackDepth]uintptr
+	// Skip runtime.Callers and callers function
+	n := runtime.Callers(2, pcs[:])
+	return runtime.CallersFrames(pcs[:n])
+}
+
+// Checks if the frame belongs to logging packages except of their tests
+func isLoggingFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	for _, pkg := range loggingPackages {
+		if strings.HasPrefix(frame.Function, pkg) && len(frame.Function) > len(pkg) {
+			switch frame.Function[len(pkg)] {
+			case '.', '/':
+				return true
+			}
+		}
+	}
+	return false
+}
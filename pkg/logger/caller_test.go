@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func helper(skip int) (Caller, bool) {
+	return FindCaller(skip)
+}
+
+func TestFindCaller(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	caller, ok := FindCaller(0)
+	if !ok {
+		t.Fatal("Expected caller")
+	}
+	if want := "logger/caller_test.go:" + strconv.Itoa(line+1); caller.String() != want {
+		t.Errorf("invalid caller:\ngot:  %s\nwant: %s", caller, want)
+	}
+	if caller.FuncName() != "logger.TestFindCaller" {
+		t.Error("invalid caller function:", caller.FuncName())
+	}
+	caller, _ = helper(0)
+	if caller.FuncName() != "logger.helper" {
+		t.Error("invalid caller function:", caller.FuncName())
+	}
+	caller, _ = helper(1)
+	if caller.FuncName() != "logger.TestFindCaller" {
+		t.Error("invalid caller function with skipped frame:", caller.FuncName())
+	}
+	if _, ok = FindCaller(maxStackDepth); ok {
+		t.Error("Expected absent caller for deep skip")
+	}
+}
+
+func TestStack(t *testing.T) {
+	stack := Stack(0)
+	if !strings.HasPrefix(stack, "github.com/takama/k8sapp/pkg/logger.TestStack\n\t") {
+		t.Error("Expected stack from the test function, got", stack)
+	}
+	if !strings.Contains(stack, "testing.tRunner") {
+		t.Error("Expected full stack, got", stack)
+	}
+}
+
+func TestLoggingFrames(t *testing.T) {
+	for function, want := range map[string]bool{
+		"github.com/takama/k8sapp/pkg/logger.Exit":                       true,
+		"github.com/takama/k8sapp/pkg/logger/standard.(*stdLogger).Info": true,
+		"github.com/takama/k8sapp/pkg/loggerx.Info":                      false,
+		"github.com/takama/k8sapp/pkg/handlers.(*Handler).Info":          false,
+		"github.com/sirupsen/logrus.(*Entry).Info":                       true,
+		"log.Printf":              true,
+		"log/slog.(*Logger).Info": true,
+		"logistics.Run":           false,
+	} {
+		if got := isLoggingFrame(runtimeFrame(function, "file.go")); got != want {
+			t.Errorf("invalid logging frame %s: got %t, want %t", function, got, want)
+		}
+	}
+	if isLoggingFrame(runtimeFrame("github.com/takama/k8sapp/pkg/logger.TestX", "logger_test.go")) {
+		t.Error("Frames of tests must not be skipped")
+	}
+}
+
+func runtimeFrame(function, file string) runtime.Frame {
+	return runtime.Frame{Function: function, File: file}
+}
+
+func TestAnnotations(t *testing.T) {
+	fields := Annotations(LevelInfo, true, true, 0)
+	if !strings.HasPrefix(fields[FieldCaller].(string), "logger/caller_test.go:") {
+		t.Error("invalid caller:", fields[FieldCaller])
+	}
+	if fields[FieldFunction] != "logger.TestAnnotations" {
+		t.Error("invalid caller function:", fields[FieldFunction])
+	}
+	if _, ok := fields[FieldStack]; ok {
+		t.Error("Expected stack for error messages only")
+	}
+	fields = Annotations(LevelError, false, true, 0)
+	if _, ok := fields[FieldCaller]; ok {
+		t.Error("Expected disabled caller")
+	}
+	if !strings.Contains(fields[FieldStack].(string), "logger.TestAnnotations") {
+		t.Error("Expected stack of error message, got", fields[FieldStack])
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type contextKey struct{}
+
+// holder keeps loggers of different types in atomic.Value
+type holder struct {
+	Logger
+}
+
+var defaultLogger atomic.Value
+
+// NewContext returns a copy of the context which carries the logger
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns the logger stored in the context,
+// the default logger is returned if the context has no logger
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if log, ok := ctx.Value(contextKey{}).(Logger); ok && log != nil {
+			return log
+		}
+	}
+	return Default()
+}
+
+// SetDefault makes the logger default for contexts without a logger
+func SetDefault(log Logger) {
+	defaultLogger.Store(holder{log})
+}
+
+// Default returns the default logger, messages are discarded
+// until it is defined by SetDefault
+func Default() Logger {
+	if h, ok := defaultLogger.Load().(holder); ok && h.Logger != nil {
+		return h.Logger
+	}
+	return discard{}
+}
+
+// discard implements the Logger interface and drops all messages,
+// fatal messages still terminate the application
+type discard struct{}
+
+func (discard) Debug(v ...interface{})                 {}
+func (discard) Debugf(format string, v ...interface{}) {}
+func (discard) Info(v ...interface{})                  {}
+func (discard) Infof(format string, v ...interface{})  {}
+func (discard) Warn(v ...interface{})                  {}
+func (discard) Warnf(format string, v ...interface{})  {}
+func (discard) Error(v ...interface{})                 {}
+func (discard) Errorf(format string, v ...interface{}) {}
+func (discard) Fatal(v ...interface{})                 { Exit(1) }
+func (discard) Fatalf(format string, v ...interface{}) { Exit(1) }
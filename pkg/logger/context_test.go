@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	defer SetDefault(nil)
+	if _, ok := FromContext(context.Background()).(discard); !ok {
+		t.Error("Expected discarding logger by default")
+	}
+	service := &messages{Leveler: NewAtomicLevel(LevelDebug)}
+	SetDefault(service)
+	if FromContext(context.Background()) != service {
+		t.Error("Expected default logger for context without logger")
+	}
+	request := WithFields(service, Fields{"request_id": "1"})
+	ctx := NewContext(context.Background(), request)
+	if FromContext(ctx) != request {
+		t.Error("Expected logger stored in the context")
+	}
+	FromContext(ctx).Info("message")
+	if len(service.list) != 1 || service.list[0] != "message request_id=1" {
+		t.Error("Expected message with fields, got", service.list)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	exitMutex    sync.Mutex
+	exitFunc     = os.Exit
+	exitHandlers []func()
+	flushers     []func() error
+)
+
+// RegisterFlusher appends a function which writes buffered messages,
+// e.g. of asynchronous outputs. Flushers are called by Flush and by Exit.
+func RegisterFlusher(flush func() error) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	flushers = append(flushers, flush)
+}
+
+// ResetFlushers removes registered flushers, e.g. in cleanup of tests
+func ResetFlushers() {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	flushers = nil
+}
+
+// Flush calls registered flushers and returns the first error,
+// it should be called before termination of the process
+func Flush() error {
+	exitMutex.Lock()
+	list := make([]func() error, len(flushers))
+	copy(list, flushers)
+	exitMutex.Unlock()
+	var err error
+	for _, flush := range list {
+		if e := flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// RegisterExitHandler appends a handler (e.g. flush or close of outputs)
+// which is called by Exit before termination of the process.
+// Handlers are called in reverse order of their registration.
+func RegisterExitHandler(handler func()) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	exitHandlers = append(exitHandlers, handler)
+}
+
+// SetExitFunc replaces the function which terminates the process (os.Exit by default)
+// and returns the previous one. It is useful to check Fatal messages in tests.
+func SetExitFunc(exit func(code int)) func(code int) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	previous := exitFunc
+	exitFunc = exit
+	return previous
+}
+
+// Exit flushes buffered messages, runs registered exit handlers
+// and terminates the process with the code.
+// All logger implementations should use it after output of Fatal messages.
+func Exit(code int) {
+	exitMutex.Lock()
+	handlers := make([]func(), len(exitHandlers))
+	copy(handlers, exitHandlers)
+	exit := exitFunc
+	exitMutex.Unlock()
+	if err := Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "Logger flush error:", err)
+	}
+	for i := len(handlers) - 1; i >= 0; i-- {
+		runExitHandler(handlers[i])
+	}
+	exit(code)
+}
+
+// Runs exit handler and prevents termination by a panic inside it
+func runExitHandler(handler func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Fprintln(os.Stderr, "Logger exit handler error:", err)
+		}
+	}()
+	handler()
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExit(t *testing.T) {
+	var calls []string
+	code := -1
+	previous := SetExitFunc(func(c int) {
+		calls = append(calls, "exit")
+		code = c
+	})
+	defer SetExitFunc(previous)
+	defer func(handlers []func()) { exitHandlers = handlers }(exitHandlers)
+
+	RegisterExitHandler(func() { calls = append(calls, "close") })
+	RegisterExitHandler(func() { panic("failed flush") })
+	RegisterExitHandler(func() { calls = append(calls, "flush") })
+	Exit(1)
+
+	if code != 1 {
+		t.Error("Expected exit code 1, got", code)
+	}
+	if want := []string{"flush", "close", "exit"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("invalid order of exit calls:\ngot:  %v\nwant: %v", calls, want)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	var calls []string
+	previous := SetExitFunc(func(int) { calls = append(calls, "exit") })
+	defer SetExitFunc(previous)
+	defer func(list []func() error) { flushers = list }(flushers)
+	defer func(handlers []func()) { exitHandlers = handlers }(exitHandlers)
+
+	failure := errors.New("flush failure")
+	RegisterExitHandler(func() { calls = append(calls, "close") })
+	RegisterFlusher(func() error { calls = append(calls, "stdout"); return nil })
+	RegisterFlusher(func() error { calls = append(calls, "stderr"); return failure })
+	if err := Flush(); err != failure {
+		t.Error("Expected", failure, "got", err)
+	}
+	calls = nil
+	Exit(1)
+	if want := []string{"stdout", "stderr", "close", "exit"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("invalid order of exit calls:\ngot:  %v\nwant: %v", calls, want)
+	}
+	ResetFlushers()
+	calls = nil
+	if err := Flush(); err != nil || calls != nil {
+		t.Error("Expected no flushers after reset, got", calls, err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldLogger defines the interface for a logger which supports
+// fields attached to all of its messages
+type FieldLogger interface {
+	// WithFields returns logger which adds the fields into every message
+	WithFields(fields Fields) Logger
+}
+
+// Wrapper defines the interface for a logger which wraps another one
+type Wrapper interface {
+	// Unwrap returns the wrapped logger
+	Unwrap() Logger
+}
+
+// FieldPanic contains value of a recovered panic,
+// error reporters report such messages as panics
+const FieldPanic = "panic"
+
+// Fields of request loggers, error reporters report them as request data
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldPath      = "path"
+	FieldMethod    = "method"
+	FieldHost      = "host"
+	FieldScheme    = "scheme"
+)
+
+// WithFields returns logger which adds the fields into every message,
+// fields are appended to the text of messages if the logger
+// does not implement FieldLogger interface
+func WithFields(log Logger, fields Fields) Logger {
+	if len(fields) == 0 {
+		return log
+	}
+	if fieldLogger, ok := log.(FieldLogger); ok {
+		return fieldLogger.WithFields(fields)
+	}
+	if parent, ok := log.(*fieldsLogger); ok {
+		merged := make(Fields, len(parent.fields)+len(fields))
+		for key, value := range parent.fields {
+			merged[key] = value
+		}
+		for key, value := range fields {
+			merged[key] = value
+		}
+		log, fields = parent.log, merged
+	}
+	return &fieldsLogger{log: log, fields: fields, text: formatFields(fields)}
+}
+
+// fieldsLogger appends fields in "key=value" notation to the messages
+type fieldsLogger struct {
+	log    Logger
+	fields Fields
+	text   string
+}
+
+// Named returns logger of the component with the same fields
+func (l *fieldsLogger) Named(name string) Logger {
+	if _, ok := l.log.(NamedLogger); ok {
+		return &fieldsLogger{log: Named(l.log, name), fields: l.fields, text: l.text}
+	}
+	parent, _ := l.fields[FieldComponent].(string)
+	return WithFields(l, Fields{FieldComponent: JoinName(parent, name)})
+}
+
+// Unwrap returns the wrapped logger
+func (l *fieldsLogger) Unwrap() Logger {
+	return l.log
+}
+
+// Level returns the current log level
+func (l *fieldsLogger) Level() Level {
+	if leveler, ok := l.log.(Leveler); ok {
+		return leveler.Level()
+	}
+	return LevelDebug
+}
+
+// SetLevel changes the log level
+func (l *fieldsLogger) SetLevel(level Level) {
+	if leveler, ok := l.log.(Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (l *fieldsLogger) Debug(v ...interface{}) {
+	l.log.Debug(l.values(v)...)
+}
+
+// Debug logs a debug message with format
+func (l *fieldsLogger) Debugf(format string, v ...interface{}) {
+	l.log.Debugf(format+"%s", l.values(v)...)
+}
+
+// Info logs a info message
+func (l *fieldsLogger) Info(v ...interface{}) {
+	l.log.Info(l.values(v)...)
+}
+
+// Info logs a info message with format
+func (l *fieldsLogger) Infof(format string, v ...interface{}) {
+	l.log.Infof(format+"%s", l.values(v)...)
+}
+
+// Warn logs a warning message.
+func (l *fieldsLogger) Warn(v ...interface{}) {
+	l.log.Warn(l.values(v)...)
+}
+
+// Warn logs a warning message with format.
+func (l *fieldsLogger) Warnf(format string, v ...interface{}) {
+	l.log.Warnf(format+"%s", l.values(v)...)
+}
+
+// Error logs an error message
+func (l *fieldsLogger) Error(v ...interface{}) {
+	l.log.Error(l.values(v)...)
+}
+
+// Error logs an error message with format
+func (l *fieldsLogger) Errorf(format string, v ...interface{}) {
+	l.log.Errorf(format+"%s", l.values(v)...)
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (l *fieldsLogger) Fatal(v ...interface{}) {
+	l.log.Fatal(l.values(v)...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (l *fieldsLogger) Fatalf(format string, v ...interface{}) {
+	l.log.Fatalf(format+"%s", l.values(v)...)
+}
+
+func (l *fieldsLogger) values(v []interface{}) []interface{} {
+	values := make([]interface{}, len(v), len(v)+1)
+	copy(values, v)
+	return append(values, l.text)
+}
+
+// formatFields returns fields sorted by keys in " key=value" notation
+func formatFields(fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var text strings.Builder
+	for _, key := range keys {
+		value := fmt.Sprint(fields[key])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		text.WriteString(" " + key + "=" + value)
+	}
+	return text.String()
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+// messages collects formatted messages of all levels
+type messages struct {
+	Leveler
+	list []string
+}
+
+func (m *messages) add(msg string)                         { m.list = append(m.list, msg) }
+func (m *messages) Debug(v ...interface{})                 { m.add(fmt.Sprint(v...)) }
+func (m *messages) Debugf(format string, v ...interface{}) { m.add(fmt.Sprintf(format, v...)) }
+func (m *messages) Info(v ...interface{})                  { m.add(fmt.Sprint(v...)) }
+func (m *messages) Infof(format string, v ...interface{})  { m.add(fmt.Sprintf(format, v...)) }
+func (m *messages) Warn(v ...interface{})                  { m.add(fmt.Sprint(v...)) }
+func (m *messages) Warnf(format string, v ...interface{})  { m.add(fmt.Sprintf(format, v...)) }
+func (m *messages) Error(v ...interface{})                 { m.add(fmt.Sprint(v...)) }
+func (m *messages) Errorf(format string, v ...interface{}) { m.add(fmt.Sprintf(format, v...)) }
+func (m *messages) Fatal(v ...interface{})                 { m.add(fmt.Sprint(v...)) }
+func (m *messages) Fatalf(format string, v ...interface{}) { m.add(fmt.Sprintf(format, v...)) }
+
+func TestWithFields(t *testing.T) {
+	log := &messages{Leveler: NewAtomicLevel(LevelInfo)}
+	if WithFields(log, nil) != log {
+		t.Error("Expected the same logger without fields")
+	}
+	child := WithFields(log, Fields{"id": 1, "route": "/"})
+	child = WithFields(child, Fields{"user": "John Doe", "id": 2})
+	child.Info("message ", 1)
+	child.Errorf("%s message", "error")
+	want := []string{
+		`message 1 id=2 route=/ user="John Doe"`,
+		`error message id=2 route=/ user="John Doe"`,
+	}
+	if len(log.list) != len(want) {
+		t.Fatal("Expected", len(want), "messages, got", log.list)
+	}
+	for i, msg := range log.list {
+		if msg != want[i] {
+			t.Errorf("invalid message:\ngot:  %v\nwant: %v", msg, want[i])
+		}
+	}
+	child.(Leveler).SetLevel(LevelError)
+	if log.Level() != LevelError {
+		t.Error("Expected level of the parent logger", LevelError, "got", log.Level())
+	}
+}
@@ -5,8 +5,11 @@
 package logger
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 // Level defines log levels
@@ -29,6 +32,84 @@ func (l Level) String() string {
 	}
 }
 
+// ParseLevel returns log level by its case-insensitive name,
+// numeric values of known levels are accepted for backward compatibility
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "fatal":
+		return LevelFatal, nil
+	}
+	if level, err := strconv.Atoi(strings.TrimSpace(name)); err == nil {
+		return numericLevel(level)
+	}
+	return LevelDebug, fmt.Errorf("unknown log level %q, %s", name, validLevels)
+}
+
+// validLevels describes accepted values of log levels in errors
+var validLevels = fmt.Sprintf(
+	"expected one of: debug, info, warning, error, fatal or a number from %d to %d", LevelDebug, LevelFatal,
+)
+
+// numericLevel returns log level by its number in the range of known levels
+func numericLevel(level int) (Level, error) {
+	if level < int(LevelDebug) || level > int(LevelFatal) {
+		return LevelDebug, fmt.Errorf("unknown log level %d, %s", level, validLevels)
+	}
+	return Level(level), nil
+}
+
+// Decode implements envconfig.Decoder interface
+func (l *Level) Decode(value string) error {
+	return l.UnmarshalText([]byte(value))
+}
+
+// MarshalText implements encoding.TextMarshaler interface
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface,
+// both names and numeric values of levels are accepted
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var level int
+		if json.Unmarshal(data, &level) != nil {
+			return fmt.Errorf("invalid log level %s, expected a name or a number", data)
+		}
+		parsed, err := numericLevel(level)
+		if err != nil {
+			return err
+		}
+		*l = parsed
+		return nil
+	}
+	return l.UnmarshalText([]byte(name))
+}
+
 // Log levels
 const (
 	LevelDebug Level = iota
@@ -69,6 +150,8 @@ type Logger interface {
 type Config struct {
 	// Level is the maximum level to output, logs with lower level are discarded.
 	Level
+	// Levels of named component loggers, other components use Level
+	Levels Levels
 	// Fields defines default fields to use with all messages.
 	Fields
 	// Output destination for levels: debug, info, warn
@@ -79,4 +162,26 @@ type Config struct {
 	Time bool
 	// Use UTC time
 	UTC bool
+	// Use JSON format of messages if it is supported by logger
+	JSON bool
+	// Add file:line and function of the caller into messages
+	Caller bool
+	// Number of additional frames between the caller and the logger
+	CallerSkip int
+	// Add stack trace into error and fatal messages
+	Stacktrace bool
+	// Sink selects an output target instead of Out and Err: SinkSyslog or SinkJournald
+	Sink string
+	// Network of the sink socket: "unixgram", "unix", "udp" or "tcp"
+	SinkNetwork string
+	// Address of the sink socket, a default socket of the sink is used if empty
+	SinkAddress string
+	// Name of the application in messages of sinks
+	Name string
 }
+
+// Output targets of messages
+const (
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+)
@@ -1,6 +1,10 @@
 package logger
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 const (
 	customLevel       Level = 17
@@ -18,3 +22,92 @@ func TestLevelString(t *testing.T) {
 		t.Errorf("invalid log level:\ngot:  %s\nwant: %s", customLevel.String(), customLevelString)
 	}
 }
+
+func TestAtomicLevel(t *testing.T) {
+	var leveler Leveler = NewAtomicLevel(LevelWarn)
+	if leveler.Level() != LevelWarn {
+		t.Errorf("invalid log level:\ngot:  %s\nwant: %s", leveler.Level(), LevelWarn)
+	}
+	leveler.SetLevel(LevelDebug)
+	if leveler.Level() != LevelDebug {
+		t.Errorf("invalid log level:\ngot:  %s\nwant: %s", leveler.Level(), LevelDebug)
+	}
+	level := NewAtomicLevel(LevelError)
+	if level.Enabled(LevelWarn) {
+		t.Error("Expected disabled warn messages for error log level")
+	}
+	if !level.Enabled(LevelFatal) {
+		t.Error("Expected enabled fatal messages for error log level")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]Level{
+		"debug":   LevelDebug,
+		"INFO":    LevelInfo,
+		"warn":    LevelWarn,
+		"Warning": LevelWarn,
+		"error":   LevelError,
+		" fatal ": LevelFatal,
+		"1":       LevelInfo,
+		"4":       LevelFatal,
+	} {
+		level, err := ParseLevel(name)
+		if err != nil {
+			t.Errorf("Unexpected error for %q log level: %s", name, err)
+		}
+		if level != want {
+			t.Errorf("invalid log level for %q:\ngot:  %s\nwant: %s", name, level, want)
+		}
+	}
+	for _, name := range []string{"", "verbose", "warn1", "17", "-5"} {
+		_, err := ParseLevel(name)
+		if err == nil || !strings.Contains(err.Error(), "unknown log level") || !strings.Contains(err.Error(), "warning") {
+			t.Errorf("Expected unknown log level error with valid names for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestLevelDecode(t *testing.T) {
+	var level Level
+	if err := level.Decode("Error"); err != nil || level != LevelError {
+		t.Errorf("invalid decoded log level: %s, error: %v", level, err)
+	}
+	if err := level.Decode("trace"); err == nil {
+		t.Error("Expected error for unknown log level")
+	}
+	if level != LevelError {
+		t.Error("Expected unchanged log level after error, got", level)
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Level Level `json:"level"`
+	}{LevelWarn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"level":"warn"}` {
+		t.Errorf("invalid encoded log level:\ngot:  %s\nwant: %s", data, `{"level":"warn"}`)
+	}
+	for data, want := range map[string]Level{
+		`"debug"`:   LevelDebug,
+		`"WARNING"`: LevelWarn,
+		`3`:         LevelError,
+	} {
+		var level Level
+		if err := json.Unmarshal([]byte(data), &level); err != nil {
+			t.Errorf("Unexpected error for %s log level: %s", data, err)
+		}
+		if level != want {
+			t.Errorf("invalid log level for %s:\ngot:  %s\nwant: %s", data, level, want)
+		}
+	}
+	for _, data := range []string{`"trace"`, `true`, `1.5`, `17`, `-1`} {
+		var level Level
+		if err := json.Unmarshal([]byte(data), &level); err == nil {
+			t.Errorf("Expected error for %s log level", data)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package loggertest implements utilities for testing of loggers
+package loggertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Adapter describes a logger implementation under conformance tests
+type Adapter struct {
+	// New creates a logger with the specified configuration
+	New func(cfg *logger.Config) logger.Logger
+	// Fields is true if the logger outputs default fields
+	Fields bool
+	// JSON is true if the logger supports JSON output
+	JSON bool
+}
+
+var levels = []logger.Level{
+	logger.LevelDebug,
+	logger.LevelInfo,
+	logger.LevelWarn,
+	logger.LevelError,
+	logger.LevelFatal,
+}
+
+// Run checks that the logger adapter conforms to the common behavior of loggers:
+// filtering and routing of levels, formatting, runtime levels and exit on Fatal
+func Run(t *testing.T, adapter Adapter) {
+	var mutex sync.Mutex
+	var codes []int
+	previous := logger.SetExitFunc(func(code int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		codes = append(codes, code)
+	})
+	defer logger.SetExitFunc(previous)
+
+	t.Run("Levels", func(t *testing.T) {
+		for _, level := range levels {
+			for _, messageLevel := range levels {
+				testLevel(t, adapter, level, messageLevel)
+			}
+		}
+	})
+	t.Run("Format", func(t *testing.T) {
+		testFormat(t, adapter)
+	})
+	t.Run("Leveler", func(t *testing.T) {
+		testLeveler(t, adapter)
+	})
+	t.Run("Time", func(t *testing.T) {
+		testTime(t, adapter)
+	})
+	if adapter.Fields {
+		t.Run("Fields", func(t *testing.T) {
+			testFields(t, adapter)
+		})
+	}
+	if adapter.JSON {
+		t.Run("JSON", func(t *testing.T) {
+			testJSON(t, adapter)
+		})
+	}
+	t.Run("Named", func(t *testing.T) {
+		testNamed(t, adapter)
+	})
+	t.Run("Concurrency", func(t *testing.T) {
+		testConcurrency(t, adapter)
+	})
+	t.Run("Exit", func(t *testing.T) {
+		mutex.Lock()
+		codes = nil
+		mutex.Unlock()
+		log := adapter.New(&logger.Config{Out: new(bytes.Buffer), Err: new(bytes.Buffer)})
+		log.Fatal("fatal message")
+		log.Fatalf("%s message", "fatal")
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(codes) != 2 || codes[0] != 1 || codes[1] != 1 {
+			t.Errorf("invalid exit codes after Fatal messages:\ngot:  %v\nwant: [1 1]", codes)
+		}
+	})
+}
+
+// Log sends the message with the specified level into the logger
+func Log(log logger.Logger, level logger.Level, message string) {
+	switch level {
+	case logger.LevelDebug:
+		log.Debug(message)
+	case logger.LevelInfo:
+		log.Info(message)
+	case logger.LevelWarn:
+		log.Warn(message)
+	case logger.LevelError:
+		log.Error(message)
+	case logger.LevelFatal:
+		log.Fatal(message)
+	}
+}
+
+// Logf sends the formatted message with the specified level into the logger
+func Logf(log logger.Logger, level logger.Level, format string, v ...interface{}) {
+	switch level {
+	case logger.LevelDebug:
+		log.Debugf(format, v...)
+	case logger.LevelInfo:
+		log.Infof(format, v...)
+	case logger.LevelWarn:
+		log.Warnf(format, v...)
+	case logger.LevelError:
+		log.Errorf(format, v...)
+	case logger.LevelFatal:
+		log.Fatalf(format, v...)
+	}
+}
+
+func testLevel(t *testing.T, adapter Adapter, level, messageLevel logger.Level) {
+	out := new(bytes.Buffer)
+	err := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{Level: level, Out: out, Err: err})
+	message := fmt.Sprintf("%s message at %s level", messageLevel, level)
+	Log(log, messageLevel, message)
+	Logf(log, messageLevel, "%s", "formatted "+message)
+	target, other := out, err
+	if messageLevel >= logger.LevelError {
+		target, other = err, out
+	}
+	if strings.Contains(other.String(), message) {
+		t.Errorf("Got %s message in wrong output: %s", messageLevel, other.String())
+	}
+	got := target.String()
+	if messageLevel >= level {
+		if !strings.Contains(got, message) || !strings.Contains(got, "formatted "+message) {
+			t.Errorf("Expected %s messages for %s output level, got: %q", messageLevel, level, got)
+		}
+	} else if got != "" {
+		t.Errorf("Got %s messages for %s output level: %q", messageLevel, level, got)
+	}
+}
+
+func testFormat(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{Out: out, Err: new(bytes.Buffer)})
+	log.Infof("formatted %s=%d", "value", 17)
+	log.Info("joined", 17)
+	got := out.String()
+	if !strings.Contains(got, "formatted value=17") {
+		t.Errorf("Expected formatted message, got: %q", got)
+	}
+	if !strings.Contains(got, "joined17") {
+		t.Errorf("Expected joined message, got: %q", got)
+	}
+	if lines := strings.Count(got, "\n"); lines != 2 {
+		t.Errorf("Expected 2 lines of messages, got %d: %q", lines, got)
+	}
+}
+
+func testLeveler(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{Level: logger.LevelWarn, Out: out, Err: new(bytes.Buffer)})
+	leveler, ok := log.(logger.Leveler)
+	if !ok {
+		t.Fatal("Expected logger which implements Leveler interface")
+	}
+	if leveler.Level() != logger.LevelWarn {
+		t.Errorf("invalid log level:\ngot:  %s\nwant: %s", leveler.Level(), logger.LevelWarn)
+	}
+	log.Info("hidden message")
+	leveler.SetLevel(logger.LevelDebug)
+	log.Debug("visible message")
+	if got := out.String(); strings.Contains(got, "hidden message") || !strings.Contains(got, "visible message") {
+		t.Errorf("Log level was not changed at runtime, got: %q", got)
+	}
+}
+
+func testTime(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{Out: out, Err: new(bytes.Buffer)})
+	log.Info("message without time")
+	year := time.Now().UTC().Format("2006")
+	if got := out.String(); strings.Contains(got, year) {
+		t.Errorf("Expected message without time, got: %q", got)
+	}
+	out.Reset()
+	log = adapter.New(&logger.Config{Out: out, Err: new(bytes.Buffer), Time: true, UTC: true})
+	log.Info("message with time")
+	if got := out.String(); !strings.Contains(got, year) {
+		t.Errorf("Expected message with time, got: %q", got)
+	}
+}
+
+func testFields(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	err := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{
+		Out:    out,
+		Err:    err,
+		Fields: logger.Fields{"service": "conformance-test"},
+	})
+	log.Info("message")
+	log.Error("message")
+	for _, got := range []string{out.String(), err.String()} {
+		if !strings.Contains(got, "service") || !strings.Contains(got, "conformance-test") {
+			t.Errorf("Expected default fields in message, got: %q", got)
+		}
+	}
+}
+
+func testJSON(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{
+		Out:    out,
+		Err:    new(bytes.Buffer),
+		Time:   true,
+		JSON:   true,
+		Fields: logger.Fields{"service": "conformance-test"},
+	})
+	log.Info("json message")
+	log.Warnf("formatted %s", "json message")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines of messages, got: %q", out.String())
+	}
+	for _, line := range lines {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Errorf("Expected JSON message, got %q: %s", line, err)
+			continue
+		}
+		if entry["service"] != "conformance-test" {
+			t.Errorf("Expected default fields in JSON message, got: %q", line)
+		}
+		if !strings.Contains(line, "json message") {
+			t.Errorf("Expected message in JSON, got: %q", line)
+		}
+	}
+}
+
+func testConcurrency(t *testing.T, adapter Adapter) {
+	out := new(syncBuffer)
+	log := adapter.New(&logger.Config{Out: out, Err: out, Time: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				Logf(log, levels[j%4], "goroutine %d message %d", n, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if lines := strings.Count(out.String(), "\n"); lines != 8*50 {
+		t.Errorf("Expected %d lines of messages, got %d", 8*50, lines)
+	}
+}
+
+// syncBuffer is a buffer which is safe for concurrent writes
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// testNamed checks names and levels of component loggers
+func testNamed(t *testing.T, adapter Adapter) {
+	out := new(bytes.Buffer)
+	log := adapter.New(&logger.Config{
+		Level:  logger.LevelInfo,
+		Levels: logger.Levels{"http": logger.LevelWarn, "db.sql": logger.LevelDebug},
+		Out:    out,
+		Err:    out,
+	})
+	if _, ok := log.(logger.NamedLogger); !ok {
+		t.Fatalf("Expected named loggers support, got %T", log)
+	}
+	http := logger.Named(log, "http")
+	sql := logger.Named(logger.Named(log, "db"), "sql")
+	signals := logger.Named(log, "signals")
+	http.Info("hidden http message")
+	http.Warn("http message")
+	sql.Debug("sql message")
+	signals.Debug("hidden signals message")
+	signals.Info("signals message")
+	log.Debug("hidden service message")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := map[string]string{"http message": "http", "sql message": "db.sql", "signals message": "signals"}
+	if len(lines) != len(want) {
+		t.Fatalf("invalid messages of components:\ngot:  %q\nwant: %d messages", lines, len(want))
+	}
+	for _, line := range lines {
+		for message, name := range want {
+			if strings.Contains(line, message) && !strings.Contains(line, name) {
+				t.Errorf("Expected component %s in the message, got %s", name, line)
+			}
+		}
+	}
+	// Components without their own level share level of the parent
+	log.(logger.Leveler).SetLevel(logger.LevelDebug)
+	if level := signals.(logger.Leveler).Level(); level != logger.LevelDebug {
+		t.Error("Expected level of the parent", logger.LevelDebug, "got", level)
+	}
+	if level := http.(logger.Leveler).Level(); level != logger.LevelWarn {
+		t.Error("Expected level of the component", logger.LevelWarn, "got", level)
+	}
+}
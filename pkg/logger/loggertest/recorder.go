@@ -0,0 +1,236 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package loggertest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Entry contains a recorded log message
+type Entry struct {
+	Level   logger.Level
+	Message string
+	// Fields are collected from logger.Fields values of the message
+	Fields logger.Fields
+	Time   time.Time
+	// Caller of the logger outside of logging packages
+	Caller logger.Caller
+}
+
+func (e Entry) String() string {
+	return "[" + e.Level.String() + "] " + e.Message
+}
+
+// Recorder is an in-memory logger which records messages to check them in tests
+type Recorder struct {
+	*logger.AtomicLevel
+	*records
+	fields logger.Fields
+	name   string
+	// Levels of component loggers which are created by Named
+	Levels logger.Levels
+}
+
+// records are shared between the recorder and its loggers with fields
+type records struct {
+	mutex   sync.RWMutex
+	entries []Entry
+	exits   int
+}
+
+// New returns a recorder of messages with debug level
+func New() *Recorder {
+	return &Recorder{
+		AtomicLevel: logger.NewAtomicLevel(logger.LevelDebug),
+		records:     new(records),
+	}
+}
+
+// WithFields returns logger which adds the fields into every message,
+// its messages are recorded by the same recorder
+func (r *Recorder) WithFields(fields logger.Fields) logger.Logger {
+	merged := make(logger.Fields, len(r.fields)+len(fields))
+	for key, value := range r.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Recorder{
+		AtomicLevel: r.AtomicLevel, records: r.records, fields: merged, name: r.name, Levels: r.Levels,
+	}
+}
+
+// Named returns logger of the component, its messages are recorded
+// by the same recorder with the component field
+func (r *Recorder) Named(name string) logger.Logger {
+	name = logger.JoinName(r.name, name)
+	child := r.WithFields(logger.Fields{logger.FieldComponent: name}).(*Recorder)
+	child.name = name
+	child.AtomicLevel = r.Levels.Level(name, r.AtomicLevel)
+	return child
+}
+
+// Debug logs a debug message
+func (r *Recorder) Debug(v ...interface{}) {
+	r.record(logger.LevelDebug, "", v)
+}
+
+// Debug logs a debug message with format
+func (r *Recorder) Debugf(format string, v ...interface{}) {
+	r.record(logger.LevelDebug, format, v)
+}
+
+// Info logs a info message
+func (r *Recorder) Info(v ...interface{}) {
+	r.record(logger.LevelInfo, "", v)
+}
+
+// Info logs a info message with format
+func (r *Recorder) Infof(format string, v ...interface{}) {
+	r.record(logger.LevelInfo, format, v)
+}
+
+// Warn logs a warning message.
+func (r *Recorder) Warn(v ...interface{}) {
+	r.record(logger.LevelWarn, "", v)
+}
+
+// Warn logs a warning message with format.
+func (r *Recorder) Warnf(format string, v ...interface{}) {
+	r.record(logger.LevelWarn, format, v)
+}
+
+// Error logs an error message
+func (r *Recorder) Error(v ...interface{}) {
+	r.record(logger.LevelError, "", v)
+}
+
+// Error logs an error message with format
+func (r *Recorder) Errorf(format string, v ...interface{}) {
+	r.record(logger.LevelError, format, v)
+}
+
+// Fatal records an error message and the exit, the process is not terminated
+func (r *Recorder) Fatal(v ...interface{}) {
+	r.record(logger.LevelFatal, "", v)
+	r.exit()
+}
+
+// Fatalf records an error message with format and the exit,
+// the process is not terminated
+func (r *Recorder) Fatalf(format string, v ...interface{}) {
+	r.record(logger.LevelFatal, format, v)
+	r.exit()
+}
+
+// Exited reports whether the exit was requested by Fatal messages
+func (r *Recorder) Exited() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.exits > 0
+}
+
+func (r *Recorder) exit() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.exits++
+}
+
+func (r *Recorder) record(level logger.Level, format string, v []interface{}) {
+	if !r.Enabled(level) {
+		return
+	}
+	entry := Entry{Level: level, Time: time.Now()}
+	entry.Caller, _ = logger.FindCaller(0)
+	if len(r.fields) > 0 {
+		entry.Fields = make(logger.Fields, len(r.fields))
+		for key, field := range r.fields {
+			entry.Fields[key] = field
+		}
+	}
+	if format != "" {
+		entry.Message = fmt.Sprintf(format, v...)
+	} else {
+		values := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			if fields, ok := value.(logger.Fields); ok {
+				if entry.Fields == nil {
+					entry.Fields = make(logger.Fields, len(fields))
+				}
+				for key, field := range fields {
+					entry.Fields[key] = field
+				}
+				continue
+			}
+			values = append(values, value)
+		}
+		entry.Message = fmt.Sprint(values...)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns all recorded messages
+func (r *Recorder) Entries() []Entry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Filter returns recorded messages with the specified level
+func (r *Recorder) Filter(level logger.Level) (entries []Entry) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, entry := range r.entries {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// Has checks if there is a message with the specified level
+func (r *Recorder) Has(level logger.Level) bool {
+	return r.Count(level) > 0
+}
+
+// Contains checks if there is a message with the specified level
+// which contains the substring
+func (r *Recorder) Contains(level logger.Level, substr string) bool {
+	for _, entry := range r.Filter(level) {
+		if strings.Contains(entry.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns number of messages with the specified level
+func (r *Recorder) Count(level logger.Level) int {
+	return len(r.Filter(level))
+}
+
+// Len returns number of all recorded messages
+func (r *Recorder) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.entries)
+}
+
+// Reset removes all recorded messages
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = nil
+}
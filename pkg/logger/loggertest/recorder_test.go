@@ -0,0 +1,89 @@
+package loggertest
+
+import (
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+func TestRecorder(t *testing.T) {
+	var log logger.Logger = New()
+	r := log.(*Recorder)
+	log.Debug("debug message")
+	log.Infof("%s message", "info")
+	log.Warn("warn message", logger.Fields{"key": "value"})
+	log.Error("error message")
+	if r.Exited() {
+		t.Error("Unexpected exit before Fatal message")
+	}
+	log.Fatalf("fatal %d", 1)
+	if !r.Exited() {
+		t.Error("Expected recorded exit after Fatal message")
+	}
+	if r.Len() != 5 {
+		t.Error("Expected 5 messages, got", r.Len())
+	}
+	for _, level := range levels {
+		if !r.Has(level) || r.Count(level) != 1 {
+			t.Errorf("Expected one %s message, got %d", level, r.Count(level))
+		}
+	}
+	if !r.Contains(logger.LevelInfo, "info message") {
+		t.Error("Expected info message, got", r.Filter(logger.LevelInfo))
+	}
+	if r.Contains(logger.LevelError, "info message") {
+		t.Error("Expected info message with info level only")
+	}
+	warn := r.Filter(logger.LevelWarn)[0]
+	if warn.Message != "warn message" || warn.Fields["key"] != "value" || warn.Time.IsZero() {
+		t.Error("invalid recorded message:", warn, warn.Fields, warn.Time)
+	}
+	if warn.Caller.FuncName() != "loggertest.TestRecorder" {
+		t.Error("invalid caller of message:", warn.Caller.FuncName())
+	}
+	if s := warn.String(); s != "[warn] warn message" {
+		t.Error("invalid string of message:", s)
+	}
+
+	r.SetLevel(logger.LevelError)
+	log.Warn("hidden message")
+	if r.Count(logger.LevelWarn) != 1 {
+		t.Error("Expected filtered warn message")
+	}
+	r.Reset()
+	if len(r.Entries()) != 0 {
+		t.Error("Expected empty recorder after reset, got", r.Entries())
+	}
+}
+
+func TestRecorderWithFields(t *testing.T) {
+	r := New()
+	child := r.WithFields(logger.Fields{"id": 1})
+	child.(logger.FieldLogger).WithFields(logger.Fields{"route": "/"}).Info("message", logger.Fields{"key": "value"})
+	r.Info("parent message")
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 messages in the parent recorder, got", len(entries))
+	}
+	want := logger.Fields{"id": 1, "route": "/", "key": "value"}
+	for key, value := range want {
+		if entries[0].Fields[key] != value {
+			t.Errorf("invalid %s field:\ngot:  %v\nwant: %v", key, entries[0].Fields[key], value)
+		}
+	}
+	if entries[1].Fields != nil {
+		t.Error("Expected message of the parent without fields, got", entries[1].Fields)
+	}
+}
+
+func TestRecorderNamed(t *testing.T) {
+	r := New()
+	r.Levels = logger.Levels{"db.sql": logger.LevelError}
+	sql := logger.Named(logger.Named(r, "db"), "sql")
+	sql.Warn("hidden message")
+	sql.Error("sql message")
+	entries := r.Entries()
+	if len(entries) != 1 || entries[0].Fields[logger.FieldComponent] != "db.sql" {
+		t.Error("Expected error message of the component, got", entries)
+	}
+}
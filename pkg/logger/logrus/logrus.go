@@ -5,16 +5,197 @@
 package logrus
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/takama/k8sapp/pkg/logger"
 )
 
 // New creates "github.com/sirupsen/logrus" logger
-func New(config *logger.Config) logger.Logger {
-	logger := logrus.New()
-	logger.Level = logrusLevelConverter(config.Level)
-	logger.WithFields(logrus.Fields(config.Fields))
-	return logger
+func New(cfg *logger.Config) logger.Logger {
+	if cfg.Out == nil {
+		cfg.Out = os.Stdout
+	}
+	if cfg.Err == nil {
+		cfg.Err = os.Stderr
+	}
+	log := logrus.New()
+	// Levels are filtered by logrusLogger to make them changeable at runtime
+	log.Level = logrus.DebugLevel
+	log.Out = cfg.Out
+	if cfg.JSON {
+		log.Formatter = &logrus.JSONFormatter{
+			DisableTimestamp: !cfg.Time,
+		}
+	} else {
+		log.Formatter = &logrus.TextFormatter{
+			DisableTimestamp: !cfg.Time,
+			FullTimestamp:    true,
+		}
+	}
+	return &logrusLogger{
+		AtomicLevel: logger.NewAtomicLevel(cfg.Level),
+		entry:       log.WithFields(logrus.Fields(cfg.Fields)),
+		utc:         cfg.UTC,
+		caller:      cfg.Caller,
+		callerSkip:  cfg.CallerSkip,
+		stacktrace:  cfg.Stacktrace,
+		levels:      cfg.Levels,
+		mutex:       new(sync.Mutex),
+		out:         cfg.Out,
+		err:         cfg.Err,
+	}
+}
+
+// logrusLogger implements the Logger and Leveler interfaces
+type logrusLogger struct {
+	*logger.AtomicLevel
+	entry *logrus.Entry
+	utc   bool
+
+	caller     bool
+	callerSkip int
+	stacktrace bool
+
+	// name of the component and levels of components
+	name   string
+	levels logger.Levels
+
+	// mutex is shared with loggers created by WithFields
+	mutex *sync.Mutex
+	out   io.Writer
+	err   io.Writer
+}
+
+// Named returns logger of the component, its name is added into messages
+func (l *logrusLogger) Named(name string) logger.Logger {
+	clone := *l
+	clone.name = logger.JoinName(l.name, name)
+	clone.AtomicLevel = l.levels.Level(clone.name, l.AtomicLevel)
+	clone.entry = l.entry.WithField(logger.FieldComponent, clone.name)
+	return &clone
+}
+
+// WithFields returns logger which adds the fields into every message
+func (l *logrusLogger) WithFields(fields logger.Fields) logger.Logger {
+	clone := *l
+	clone.entry = l.entry.WithFields(logrus.Fields(fields))
+	return &clone
+}
+
+// Debug logs a debug message
+func (l *logrusLogger) Debug(v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprint(v...))
+	}
+}
+
+// Debug logs a debug message with format
+func (l *logrusLogger) Debugf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+// Info logs a info message
+func (l *logrusLogger) Info(v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprint(v...))
+	}
+}
+
+// Info logs a info message with format
+func (l *logrusLogger) Infof(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprintf(format, v...))
+	}
+}
+
+// Warn logs a warning message.
+func (l *logrusLogger) Warn(v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprint(v...))
+	}
+}
+
+// Warn logs a warning message with format.
+func (l *logrusLogger) Warnf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprintf(format, v...))
+	}
+}
+
+// Error logs an error message
+func (l *logrusLogger) Error(v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprint(v...))
+	}
+}
+
+// Error logs an error message with format
+func (l *logrusLogger) Errorf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprintf(format, v...))
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (l *logrusLogger) Fatal(v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprint(v...))
+	}
+	logger.Exit(1)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (l *logrusLogger) Fatalf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprintf(format, v...))
+	}
+	logger.Exit(1)
+}
+
+// log writes an entry like logrus does, but routes it by level into Out or Err
+// and avoids a call to os.Exit that can not be intercepted in the fatal methods
+func (l *logrusLogger) log(level logger.Level, msg string) {
+	entry := logrus.NewEntry(l.entry.Logger)
+	// Formatters rename clashing fields in the data, so every entry gets its own copy
+	entry.Data = make(logrus.Fields, len(l.entry.Data))
+	for key, value := range l.entry.Data {
+		entry.Data[key] = value
+	}
+	if l.caller || l.stacktrace {
+		for key, value := range logger.Annotations(level, l.caller, l.stacktrace, l.callerSkip) {
+			entry.Data[key] = value
+		}
+	}
+	entry.Time = time.Now()
+	if l.utc {
+		entry.Time = entry.Time.UTC()
+	}
+	entry.Level = logrusLevelConverter(level)
+	entry.Message = msg
+	if err := entry.Logger.Hooks.Fire(entry.Level, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+	}
+	serialized, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
+		return
+	}
+	out := l.out
+	if level >= logger.LevelError {
+		out = l.err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, err = out.Write(serialized); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
 }
 
 func logrusLevelConverter(level logger.Level) logrus.Level {
@@ -1,16 +1,28 @@
 package logrus
 
 import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 )
 
 const (
 	customLevel logger.Level = 17
 )
 
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
 func TestLogrusLevel(t *testing.T) {
 	for _, l := range []logger.Level{
 		logger.LevelDebug,
@@ -37,3 +49,114 @@ func TestNewLogrus(t *testing.T) {
 		t.Error("Got uninitialized logrus logger")
 	}
 }
+
+func TestConformance(t *testing.T) {
+	loggertest.Run(t, loggertest.Adapter{
+		New:    New,
+		Fields: true,
+		JSON:   true,
+	})
+}
+
+func TestFormat(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:    out,
+		Err:    err,
+		Time:   true,
+		UTC:    true,
+		Fields: logger.Fields{"key": "value"},
+	})
+	log.Info("info message")
+	log.Error("error message")
+	want := "time=\"" + time.Now().UTC().Format("2006-01-02T15")
+	if got := out.String(); !strings.Contains(got, want) || !strings.Contains(got, "level=info") ||
+		!strings.Contains(got, "key=value") || !strings.Contains(got, "info message") {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if got := err.String(); !strings.Contains(got, "level=error") || !strings.Contains(got, "error message") {
+		t.Error("Expected error message in error output, got", got)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{Out: out, Fields: logger.Fields{"service": "test"}})
+	logger.WithFields(log, logger.Fields{"request_id": "abc"}).Info("request message")
+	log.Info("service message")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Expected 2 messages, got", out.String())
+	}
+	if !strings.Contains(lines[0], "request_id=abc") || !strings.Contains(lines[0], "service=test") {
+		t.Error("Expected fields of the request, got", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Error("Expected message without fields of the request, got", lines[1])
+	}
+}
+
+func TestClashingFields(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{Out: out, Fields: logger.Fields{"level": "custom"}})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("concurrent message")
+		}()
+	}
+	wg.Wait()
+	if count := strings.Count(out.String(), "concurrent message"); count != 4 {
+		t.Error("Expected 4 messages, got", out.String())
+	}
+	if data := log.(*logrusLogger).entry.Data; len(data) != 1 || data["level"] != "custom" {
+		t.Error("Expected unchanged fields of the logger, got", data)
+	}
+}
+
+func TestFatalExit(t *testing.T) {
+	code := 0
+	previous := logger.SetExitFunc(func(c int) { code = c })
+	defer logger.SetExitFunc(previous)
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Level: logger.LevelError,
+		Err:   err,
+	})
+	log.Fatal("fatal message")
+	if code != 1 {
+		t.Error("Expected exit code 1 after Fatal message, got", code)
+	}
+	if got := err.String(); !strings.Contains(got, "fatal message") || !strings.Contains(got, "level=fatal") {
+		t.Error("Expected fatal message in output, got", got)
+	}
+	code = 0
+	log.Fatalf("%s", "message")
+	if code != 1 {
+		t.Error("Expected exit code 1 after Fatalf message, got", code)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:        out,
+		Err:        err,
+		JSON:       true,
+		Caller:     true,
+		Stacktrace: true,
+	})
+	log.Info("message")
+	if got := out.String(); !strings.Contains(got, `"caller":"logrus/logrus_test.go:`) ||
+		!strings.Contains(got, `"func":"logrus.TestCaller"`) || strings.Contains(got, `"stack"`) {
+		t.Error("Expected caller of the message, got", got)
+	}
+	log.Error("message")
+	if got := err.String(); !strings.Contains(got, `"stack":"github.com/takama/k8sapp/pkg/logger/logrus.TestCaller\n`) {
+		t.Error("Expected stack trace of the message, got", got)
+	}
+}
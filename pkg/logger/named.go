@@ -0,0 +1,93 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldComponent contains name of the component logger
+const FieldComponent = "component"
+
+// NamedLogger defines the interface for a logger with named children
+// (components), their levels could be configured independently
+type NamedLogger interface {
+	// Named returns child logger of the component,
+	// names of nested children are joined by dot
+	Named(name string) Logger
+}
+
+// Named returns child logger of the component, the name is added into
+// messages as a field if the logger does not implement NamedLogger interface
+func Named(log Logger, name string) Logger {
+	if namedLogger, ok := log.(NamedLogger); ok {
+		return namedLogger.Named(name)
+	}
+	return WithFields(log, Fields{FieldComponent: name})
+}
+
+// JoinName returns full name of the nested component
+func JoinName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// Levels contains log levels of components, e.g. "http=warn,db=debug"
+type Levels map[string]Level
+
+// ParseLevels returns levels of components from comma separated
+// "name=level" pairs
+func ParseLevels(text string) (Levels, error) {
+	levels := make(Levels)
+	for _, pair := range strings.Split(text, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid component log level %q, expected name=level", pair)
+		}
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// Decode implements envconfig.Decoder interface
+func (l *Levels) Decode(value string) error {
+	levels, err := ParseLevels(value)
+	if err != nil {
+		return err
+	}
+	*l = levels
+	return nil
+}
+
+func (l Levels) String() string {
+	pairs := make([]string, 0, len(l))
+	for name, level := range l {
+		pairs = append(pairs, name+"="+level.String())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Level returns level of the component: a new one if it is configured,
+// otherwise the component shares level of the parent
+func (l Levels) Level(name string, parent *AtomicLevel) *AtomicLevel {
+	if level, ok := l[name]; ok {
+		return NewAtomicLevel(level)
+	}
+	return parent
+}
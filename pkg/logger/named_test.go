@@ -0,0 +1,38 @@
+package logger
+
+import "testing"
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels(" http=warn, db.sql=DEBUG ,,signals=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Levels{"http": LevelWarn, "db.sql": LevelDebug, "signals": LevelError}
+	if levels.String() != want.String() {
+		t.Errorf("invalid levels:\ngot:  %v\nwant: %v", levels, want)
+	}
+	for _, text := range []string{"http", "=warn", "http=verbose"} {
+		if _, err := ParseLevels(text); err == nil {
+			t.Error("Expected error for levels", text)
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	log := &messages{Leveler: NewAtomicLevel(LevelInfo)}
+	Named(Named(log, "db"), "sql").Info("message")
+	if len(log.list) != 1 || log.list[0] != "message component=db.sql" {
+		t.Error("Expected component in the message, got", log.list)
+	}
+	if name := JoinName("db", "sql"); name != "db.sql" {
+		t.Error("Expected db.sql, got", name)
+	}
+	parent := NewAtomicLevel(LevelInfo)
+	levels := Levels{"http": LevelWarn}
+	if levels.Level("db", parent) != parent {
+		t.Error("Expected level of the parent for the component without level")
+	}
+	if level := levels.Level("http", parent); level == parent || level.Level() != LevelWarn {
+		t.Error("Expected level of the component", LevelWarn, "got", level.Level())
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Config contains redaction rules
+type Config struct {
+	// Fields contains patterns of names of logger.Fields which values are masked
+	Fields []*regexp.Regexp
+	// Rules are applied to messages and string values of fields
+	Rules []Rule
+}
+
+// DefaultConfig returns config with built-in rules
+func DefaultConfig() Config {
+	rules := make([]Rule, len(DefaultRules))
+	copy(rules, DefaultRules)
+	return Config{
+		Fields: []*regexp.Regexp{SensitiveFields},
+		Rules:  rules,
+	}
+}
+
+// Redactor masks sensitive data of messages before they reach the logger
+type Redactor struct {
+	log    logger.Logger
+	config Config
+}
+
+// New returns logger which masks sensitive data of messages
+func New(log logger.Logger, config Config) *Redactor {
+	return &Redactor{log: log, config: config}
+}
+
+// WithFields returns logger which adds the fields with masked sensitive values
+// into every message
+func (r *Redactor) WithFields(fields logger.Fields) logger.Logger {
+	return New(logger.WithFields(r.log, r.RedactFields(fields)), r.config)
+}
+
+// Named returns logger of the component which masks sensitive data of messages
+func (r *Redactor) Named(name string) logger.Logger {
+	return New(logger.Named(r.log, name), r.config)
+}
+
+// Unwrap returns the wrapped logger
+func (r *Redactor) Unwrap() logger.Logger {
+	return r.log
+}
+
+// Level returns the current log level
+func (r *Redactor) Level() logger.Level {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		return leveler.Level()
+	}
+	return logger.LevelDebug
+}
+
+// SetLevel changes the log level
+func (r *Redactor) SetLevel(level logger.Level) {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (r *Redactor) Debug(v ...interface{}) {
+	if r.enabled(logger.LevelDebug) {
+		r.log.Debug(r.values(v)...)
+	}
+}
+
+// Debug logs a debug message with format
+func (r *Redactor) Debugf(format string, v ...interface{}) {
+	if r.enabled(logger.LevelDebug) {
+		r.logf(r.log.Debugf, r.log.Debug, format, v)
+	}
+}
+
+// Info logs a info message
+func (r *Redactor) Info(v ...interface{}) {
+	if r.enabled(logger.LevelInfo) {
+		r.log.Info(r.values(v)...)
+	}
+}
+
+// Info logs a info message with format
+func (r *Redactor) Infof(format string, v ...interface{}) {
+	if r.enabled(logger.LevelInfo) {
+		r.logf(r.log.Infof, r.log.Info, format, v)
+	}
+}
+
+// Warn logs a warning message.
+func (r *Redactor) Warn(v ...interface{}) {
+	if r.enabled(logger.LevelWarn) {
+		r.log.Warn(r.values(v)...)
+	}
+}
+
+// Warn logs a warning message with format.
+func (r *Redactor) Warnf(format string, v ...interface{}) {
+	if r.enabled(logger.LevelWarn) {
+		r.logf(r.log.Warnf, r.log.Warn, format, v)
+	}
+}
+
+// Error logs an error message
+func (r *Redactor) Error(v ...interface{}) {
+	if r.enabled(logger.LevelError) {
+		r.log.Error(r.values(v)...)
+	}
+}
+
+// Error logs an error message with format
+func (r *Redactor) Errorf(format string, v ...interface{}) {
+	if r.enabled(logger.LevelError) {
+		r.logf(r.log.Errorf, r.log.Error, format, v)
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (r *Redactor) Fatal(v ...interface{}) {
+	r.log.Fatal(r.values(v)...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (r *Redactor) Fatalf(format string, v ...interface{}) {
+	r.logf(r.log.Fatalf, r.log.Fatal, format, v)
+}
+
+// Redact masks sensitive data of the text
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.config.Rules {
+		text = rule.apply(text)
+	}
+	return text
+}
+
+// RedactFields returns copy of the fields with masked sensitive values
+func (r *Redactor) RedactFields(fields logger.Fields) logger.Fields {
+	redacted := make(logger.Fields, len(fields))
+	for key, value := range fields {
+		redacted[key] = r.field(key, value)
+	}
+	return redacted
+}
+
+// field returns masked value of the field, maps with string keys and slices
+// are redacted recursively, values of other types are redacted as formatted text
+func (r *Redactor) field(key string, value interface{}) interface{} {
+	for _, pattern := range r.config.Fields {
+		if pattern.MatchString(key) {
+			return Mask
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.Redact(v)
+	case []byte:
+		return r.Redact(string(v))
+	case error:
+		return errors.New(r.Redact(v.Error()))
+	case fmt.Stringer:
+		return r.Redact(v.String())
+	case logger.Fields:
+		return r.RedactFields(v)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return value
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			redacted := make(logger.Fields, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				name := iter.Key().String()
+				redacted[name] = r.field(name, iter.Value().Interface())
+			}
+			return redacted
+		}
+	case reflect.Slice, reflect.Array:
+		redacted := make([]interface{}, rv.Len())
+		for i := range redacted {
+			redacted[i] = r.field(key, rv.Index(i).Interface())
+		}
+		return redacted
+	}
+	return r.Redact(fmt.Sprint(value))
+}
+
+// values masks sensitive data of message values, consecutive values
+// except of logger.Fields are formatted together to keep spaces between them,
+// they are kept as is if they have no sensitive data
+func (r *Redactor) values(v []interface{}) []interface{} {
+	values := make([]interface{}, 0, len(v))
+	start := 0
+	for i, value := range v {
+		if fields, ok := value.(logger.Fields); ok {
+			values = r.appendValues(values, v[start:i])
+			values = append(values, r.RedactFields(fields))
+			start = i + 1
+		}
+	}
+	return r.appendValues(values, v[start:])
+}
+
+func (r *Redactor) appendValues(values, v []interface{}) []interface{} {
+	if len(v) == 0 {
+		return values
+	}
+	text := fmt.Sprint(v...)
+	if redacted := r.Redact(text); redacted != text {
+		return append(values, redacted)
+	}
+	return append(values, v...)
+}
+
+// logf logs the message with the format if sensitive data is masked in values,
+// error reporters group such messages by the format and get types of errors.
+// Otherwise the formatted message with masked sensitive data is logged by log
+func (r *Redactor) logf(
+	logf func(format string, v ...interface{}), log func(v ...interface{}), format string, v []interface{},
+) {
+	values := make([]interface{}, len(v))
+	for i, value := range v {
+		switch value := value.(type) {
+		case logger.Fields:
+			values[i] = r.RedactFields(value)
+		case string:
+			values[i] = r.Redact(value)
+		case error:
+			if text := value.Error(); r.Redact(text) != text {
+				values[i] = errors.New(r.Redact(text))
+			} else {
+				values[i] = value
+			}
+		default:
+			values[i] = value
+		}
+	}
+	text := fmt.Sprintf(format, values...)
+	if redacted := r.Redact(text); redacted != text {
+		log(redacted)
+		return
+	}
+	logf(format, values...)
+}
+
+// enabled avoids formatting of messages which are filtered by level
+func (r *Redactor) enabled(level logger.Level) bool {
+	return level >= r.Level()
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package redact
+
+import "regexp"
+
+// Mask replaces sensitive data in log messages
+const Mask = "[REDACTED]"
+
+// Rule defines sensitive values in log messages
+type Rule struct {
+	// Name of the rule
+	Name string
+	// Pattern finds sensitive values
+	Pattern *regexp.Regexp
+	// Replacement of the found values, it supports ${n} expansion of groups
+	Replacement string
+	// Match validates the found values, all of them are replaced if it is nil
+	Match func(value string) bool
+}
+
+// SensitiveFields matches names of fields which values are masked
+var SensitiveFields = regexp.MustCompile(
+	`(?i)pass(word|wd)?|secret|token|api[-_]?key|auth|cookie|credential|private[-_]?key`,
+)
+
+// Built-in rules
+var (
+	// BearerToken masks tokens of HTTP authorization headers
+	BearerToken = Rule{
+		Name:        "bearer",
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`),
+		Replacement: "${1} " + Mask,
+	}
+	// JWT masks JSON web tokens
+	JWT = Rule{
+		Name:        "jwt",
+		Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		Replacement: Mask,
+	}
+	// Email masks email addresses
+	Email = Rule{
+		Name:        "email",
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		Replacement: Mask,
+	}
+	// CardNumber masks numbers of payment cards which pass the Luhn check
+	CardNumber = Rule{
+		Name:        "card",
+		Pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replacement: Mask,
+		Match:       luhn,
+	}
+	// Credentials masks values of sensitive keys in text, e.g. "password=secret"
+	Credentials = Rule{
+		Name: "credentials",
+		Pattern: regexp.MustCompile(
+			`(?i)\b(pass(?:word|wd)?|secret|token|api[-_]?key)("?\s*[=:]\s*"?)([^\s"&,;()\[\]{}]+)`,
+		),
+		Replacement: "${1}${2}" + Mask,
+	}
+)
+
+// DefaultRules contains all of built-in rules
+var DefaultRules = []Rule{BearerToken, JWT, Email, CardNumber, Credentials}
+
+// apply replaces sensitive values of the text
+func (r Rule) apply(text string) string {
+	if r.Match == nil {
+		return r.Pattern.ReplaceAllString(text, r.Replacement)
+	}
+	return r.Pattern.ReplaceAllStringFunc(text, func(value string) string {
+		if !r.Match(value) {
+			return value
+		}
+		return r.Pattern.ReplaceAllString(value, r.Replacement)
+	})
+}
+
+// luhn checks digits of the number by Luhn algorithm, spaces and dashes are ignored
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
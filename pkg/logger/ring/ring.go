@@ -0,0 +1,271 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ring
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Entry contains a log message kept in the buffer
+type Entry struct {
+	Time    time.Time     `json:"time"`
+	Level   logger.Level  `json:"level"`
+	Message string        `json:"message"`
+	Fields  logger.Fields `json:"fields,omitempty"`
+}
+
+func (e Entry) String() string {
+	return e.Time.Format(time.RFC3339Nano) + " [" + e.Level.String() + "] " + e.Message
+}
+
+// MarshalJSON encodes the entry, errors and values of fields
+// which can not be encoded are replaced by their text
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	if len(e.Fields) > 0 {
+		fields := make(logger.Fields, len(e.Fields))
+		for key, value := range e.Fields {
+			if err, ok := value.(error); ok {
+				value = err.Error()
+			} else if _, err := json.Marshal(value); err != nil {
+				value = fmt.Sprint(value)
+			}
+			fields[key] = value
+		}
+		e.Fields = fields
+	}
+	return json.Marshal(entry(e))
+}
+
+// Buffer passes messages to the logger and keeps the last of them in memory
+type Buffer struct {
+	log    logger.Logger
+	fields logger.Fields
+	*store
+}
+
+// store is shared between the buffer and its loggers with fields
+type store struct {
+	level logger.Level
+
+	mutex       sync.RWMutex
+	entries     []Entry
+	next        int
+	full        bool
+	subscribers map[chan Entry]struct{}
+}
+
+// New returns logger which keeps the last size messages with the level or higher
+func New(log logger.Logger, size int, level logger.Level) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+	return &Buffer{
+		log: log,
+		store: &store{
+			level:       level,
+			entries:     make([]Entry, size),
+			subscribers: make(map[chan Entry]struct{}),
+		},
+	}
+}
+
+// WithFields returns logger which adds the fields into every message,
+// its messages are kept in the same buffer
+func (b *Buffer) WithFields(fields logger.Fields) logger.Logger {
+	merged := make(logger.Fields, len(b.fields)+len(fields))
+	for key, value := range b.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Buffer{log: logger.WithFields(b.log, fields), fields: merged, store: b.store}
+}
+
+// Named returns logger of the component, its messages are kept in the same buffer
+func (b *Buffer) Named(name string) logger.Logger {
+	log := logger.Named(b.log, name)
+	fields := make(logger.Fields, len(b.fields)+1)
+	for key, value := range b.fields {
+		fields[key] = value
+	}
+	parent, _ := b.fields[logger.FieldComponent].(string)
+	fields[logger.FieldComponent] = logger.JoinName(parent, name)
+	return &Buffer{log: log, fields: fields, store: b.store}
+}
+
+// Unwrap returns the wrapped logger
+func (b *Buffer) Unwrap() logger.Logger {
+	return b.log
+}
+
+// Level returns the current log level
+func (b *Buffer) Level() logger.Level {
+	if leveler, ok := b.log.(logger.Leveler); ok {
+		return leveler.Level()
+	}
+	return logger.LevelDebug
+}
+
+// SetLevel changes the log level
+func (b *Buffer) SetLevel(level logger.Level) {
+	if leveler, ok := b.log.(logger.Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (b *Buffer) Debug(v ...interface{}) {
+	b.keep(logger.LevelDebug, "", v)
+	b.log.Debug(v...)
+}
+
+// Debug logs a debug message with format
+func (b *Buffer) Debugf(format string, v ...interface{}) {
+	b.keep(logger.LevelDebug, format, v)
+	b.log.Debugf(format, v...)
+}
+
+// Info logs a info message
+func (b *Buffer) Info(v ...interface{}) {
+	b.keep(logger.LevelInfo, "", v)
+	b.log.Info(v...)
+}
+
+// Info logs a info message with format
+func (b *Buffer) Infof(format string, v ...interface{}) {
+	b.keep(logger.LevelInfo, format, v)
+	b.log.Infof(format, v...)
+}
+
+// Warn logs a warning message.
+func (b *Buffer) Warn(v ...interface{}) {
+	b.keep(logger.LevelWarn, "", v)
+	b.log.Warn(v...)
+}
+
+// Warn logs a warning message with format.
+func (b *Buffer) Warnf(format string, v ...interface{}) {
+	b.keep(logger.LevelWarn, format, v)
+	b.log.Warnf(format, v...)
+}
+
+// Error logs an error message
+func (b *Buffer) Error(v ...interface{}) {
+	b.keep(logger.LevelError, "", v)
+	b.log.Error(v...)
+}
+
+// Error logs an error message with format
+func (b *Buffer) Errorf(format string, v ...interface{}) {
+	b.keep(logger.LevelError, format, v)
+	b.log.Errorf(format, v...)
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (b *Buffer) Fatal(v ...interface{}) {
+	b.keep(logger.LevelFatal, "", v)
+	b.log.Fatal(v...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (b *Buffer) Fatalf(format string, v ...interface{}) {
+	b.keep(logger.LevelFatal, format, v)
+	b.log.Fatalf(format, v...)
+}
+
+// Entries returns kept messages with the level or higher, the oldest go first
+func (b *Buffer) Entries(level logger.Level) []Entry {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.filter(level)
+}
+
+// Follow returns kept messages with the level or higher and a channel of new ones,
+// messages are dropped if the channel is full. The cancel function must be called
+// when the channel is no longer used.
+func (b *Buffer) Follow(level logger.Level, size int) (entries []Entry, ch <-chan Entry, cancel func()) {
+	subscriber := make(chan Entry, size)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	entries = b.filter(level)
+	b.subscribers[subscriber] = struct{}{}
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, subscriber)
+			b.mutex.Unlock()
+		})
+	}
+	return entries, subscriber, cancel
+}
+
+func (b *Buffer) filter(level logger.Level) []Entry {
+	var entries []Entry
+	if b.full {
+		entries = append(entries, b.entries[b.next:]...)
+	}
+	entries = append(entries, b.entries[:b.next]...)
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if entry.Level >= level {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func (b *Buffer) keep(level logger.Level, format string, v []interface{}) {
+	if level < b.level || level < b.Level() {
+		return
+	}
+	entry := Entry{Time: time.Now(), Level: level}
+	if len(b.fields) > 0 {
+		entry.Fields = make(logger.Fields, len(b.fields))
+		for key, value := range b.fields {
+			entry.Fields[key] = value
+		}
+	}
+	if format != "" {
+		entry.Message = fmt.Sprintf(format, v...)
+	} else {
+		values := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			if fields, ok := value.(logger.Fields); ok {
+				if entry.Fields == nil {
+					entry.Fields = make(logger.Fields, len(fields))
+				}
+				for key, field := range fields {
+					entry.Fields[key] = field
+				}
+				continue
+			}
+			values = append(values, value)
+		}
+		entry.Message = fmt.Sprint(values...)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries[b.next] = entry
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.full = true
+	}
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- entry:
+		default:
+			// Slow subscribers lose messages instead of blocking the logger
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ring
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+func TestBuffer(t *testing.T) {
+	log := loggertest.New()
+	buffer := New(log, 3, logger.LevelInfo)
+	buffer.Debug("debug message")
+	buffer.Info("info message")
+	buffer.Warnf("%s message", "warn")
+	buffer.Error("error message", logger.Fields{"key": "value"})
+	buffer.Fatal("fatal message")
+	if log.Len() != 5 {
+		t.Error("Expected all of 5 messages in the logger, got", log.Len())
+	}
+	entries := buffer.Entries(logger.LevelDebug)
+	want := []string{"warn message", "error message", "fatal message"}
+	if len(entries) != len(want) {
+		t.Fatal("Expected", len(want), "last messages, got", entries)
+	}
+	for i, entry := range entries {
+		if entry.Message != want[i] {
+			t.Errorf("invalid message:\ngot:  %v\nwant: %v", entry.Message, want[i])
+		}
+	}
+	if entries[1].Fields["key"] != "value" {
+		t.Error("Expected fields of the message, got", entries[1].Fields)
+	}
+	if entries := buffer.Entries(logger.LevelError); len(entries) != 2 {
+		t.Error("Expected 2 messages with error level or higher, got", entries)
+	}
+}
+
+func TestEntryJSON(t *testing.T) {
+	entry := Entry{Level: logger.LevelInfo, Message: "message", Fields: logger.Fields{
+		"count": 1, "error": errors.New("failed"), "done": make(chan struct{}),
+	}}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal("Expected encoded entry, got", err)
+	}
+	for _, want := range []string{`"count":1`, `"error":"failed"`, `"done":"0x`} {
+		if !strings.Contains(string(data), want) {
+			t.Error("Expected", want, "in entry, got", string(data))
+		}
+	}
+	if _, ok := entry.Fields["done"].(chan struct{}); !ok {
+		t.Error("Expected fields of the entry are not changed, got", entry.Fields)
+	}
+}
+
+func TestBufferLevel(t *testing.T) {
+	log := loggertest.New()
+	buffer := New(log, 10, logger.LevelDebug)
+	buffer.SetLevel(logger.LevelWarn)
+	if log.Level() != logger.LevelWarn {
+		t.Error("Expected level of the logger", logger.LevelWarn, "got", log.Level())
+	}
+	buffer.Info("filtered message")
+	buffer.Warn("warn message")
+	if entries := buffer.Entries(logger.LevelDebug); len(entries) != 1 {
+		t.Error("Expected messages which are passed by the logger, got", entries)
+	}
+}
+
+func TestBufferWithFields(t *testing.T) {
+	buffer := New(loggertest.New(), 10, logger.LevelDebug)
+	logger.WithFields(buffer, logger.Fields{"request_id": "1"}).Info("request message")
+	buffer.Info("service message")
+	entries := buffer.Entries(logger.LevelDebug)
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 messages, got", entries)
+	}
+	if entries[0].Fields["request_id"] != "1" || entries[1].Fields != nil {
+		t.Error("Expected fields of the request message only, got", entries)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	buffer := New(loggertest.New(), 10, logger.LevelDebug)
+	buffer.Info("old message")
+	entries, messages, cancel := buffer.Follow(logger.LevelDebug, 1)
+	if len(entries) != 1 || entries[0].Message != "old message" {
+		t.Error("Expected kept message, got", entries)
+	}
+	buffer.Info("new message")
+	// The queue is full, the message is dropped instead of blocking
+	buffer.Info("dropped message")
+	select {
+	case entry := <-messages:
+		if entry.Message != "new message" {
+			t.Error("Expected new message, got", entry.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected new message in the channel")
+	}
+	cancel()
+	cancel()
+	buffer.Info("message after cancel")
+	select {
+	case entry := <-messages:
+		t.Error("Expected no messages after cancel, got", entry.Message)
+	default:
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sampling
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Config contains parameters of sampling
+type Config struct {
+	// Interval of sampling, counters of repeated messages are reset every interval
+	Interval time.Duration
+	// First repeated messages are logged every interval, at least one
+	First int
+	// Thereafter every Thereafter-th repeated message is logged, others are dropped
+	Thereafter int
+}
+
+// Sampler limits repeated messages of the logger, it never drops fatal messages
+type Sampler struct {
+	log       logger.Logger
+	config    Config
+	component string
+	*state
+}
+
+// state of sampling is shared between the sampler and its derived loggers
+type state struct {
+	dropped uint64
+
+	mutex    sync.Mutex
+	start    time.Time
+	counters map[key]*counter
+	reported bool
+}
+
+type key struct {
+	component string
+	level     logger.Level
+	message   string
+}
+
+type counter struct {
+	count   int
+	dropped int
+}
+
+// New returns logger which samples repeated messages of the logger,
+// at least the first of repeated messages is logged every interval
+func New(log logger.Logger, config Config) *Sampler {
+	if config.First < 1 {
+		config.First = 1
+	}
+	return &Sampler{
+		log:    log,
+		config: config,
+		state: &state{
+			start:    time.Now(),
+			counters: make(map[key]*counter),
+		},
+	}
+}
+
+// Dropped returns a total number of dropped messages
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Named returns logger of the component, its messages are counted independently,
+// but dropped messages are reported and counted by the sampler
+func (s *Sampler) Named(name string) logger.Logger {
+	return &Sampler{
+		log:       logger.Named(s.log, name),
+		config:    s.config,
+		component: logger.JoinName(s.component, name),
+		state:     s.state,
+	}
+}
+
+// WithFields returns logger which adds the fields into every message,
+// its messages are sampled together with messages of the sampler
+func (s *Sampler) WithFields(fields logger.Fields) logger.Logger {
+	return &Sampler{log: logger.WithFields(s.log, fields), config: s.config, component: s.component, state: s.state}
+}
+
+// Unwrap returns the wrapped logger
+func (s *Sampler) Unwrap() logger.Logger {
+	return s.log
+}
+
+// Level returns the current log level
+func (s *Sampler) Level() logger.Level {
+	if leveler, ok := s.log.(logger.Leveler); ok {
+		return leveler.Level()
+	}
+	return logger.LevelDebug
+}
+
+// SetLevel changes the log level
+func (s *Sampler) SetLevel(level logger.Level) {
+	if leveler, ok := s.log.(logger.Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (s *Sampler) Debug(v ...interface{}) {
+	if s.sample(logger.LevelDebug, "", v) {
+		s.log.Debug(v...)
+	}
+}
+
+// Debug logs a debug message with format
+func (s *Sampler) Debugf(format string, v ...interface{}) {
+	if s.sample(logger.LevelDebug, format, v) {
+		s.log.Debugf(format, v...)
+	}
+}
+
+// Info logs a info message
+func (s *Sampler) Info(v ...interface{}) {
+	if s.sample(logger.LevelInfo, "", v) {
+		s.log.Info(v...)
+	}
+}
+
+// Info logs a info message with format
+func (s *Sampler) Infof(format string, v ...interface{}) {
+	if s.sample(logger.LevelInfo, format, v) {
+		s.log.Infof(format, v...)
+	}
+}
+
+// Warn logs a warning message.
+func (s *Sampler) Warn(v ...interface{}) {
+	if s.sample(logger.LevelWarn, "", v) {
+		s.log.Warn(v...)
+	}
+}
+
+// Warn logs a warning message with format.
+func (s *Sampler) Warnf(format string, v ...interface{}) {
+	if s.sample(logger.LevelWarn, format, v) {
+		s.log.Warnf(format, v...)
+	}
+}
+
+// Error logs an error message
+func (s *Sampler) Error(v ...interface{}) {
+	if s.sample(logger.LevelError, "", v) {
+		s.log.Error(v...)
+	}
+}
+
+// Error logs an error message with format
+func (s *Sampler) Errorf(format string, v ...interface{}) {
+	if s.sample(logger.LevelError, format, v) {
+		s.log.Errorf(format, v...)
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (s *Sampler) Fatal(v ...interface{}) {
+	s.log.Fatal(v...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (s *Sampler) Fatalf(format string, v ...interface{}) {
+	s.log.Fatalf(format, v...)
+}
+
+// sample checks if the message should be logged, formatted messages
+// are recognized as repeated by their format
+func (s *Sampler) sample(level logger.Level, format string, v []interface{}) bool {
+	if level < s.Level() {
+		return false
+	}
+	message := format
+	if message == "" {
+		message = fmt.Sprint(v...)
+	}
+	s.mutex.Lock()
+	var reports []string
+	if time.Since(s.start) >= s.config.Interval {
+		reports = s.reset()
+	}
+	logged := s.count(key{s.component, level, message})
+	s.mutex.Unlock()
+	s.report(reports)
+	return logged
+}
+
+// count counts the message and checks if it should be logged, mutex must be locked
+func (s *Sampler) count(k key) bool {
+	c, ok := s.counters[k]
+	if !ok {
+		c = new(counter)
+		s.counters[k] = c
+	}
+	c.count++
+	if c.count <= s.config.First ||
+		s.config.Thereafter > 0 && (c.count-s.config.First)%s.config.Thereafter == 0 {
+		return true
+	}
+	c.dropped++
+	atomic.AddUint64(&s.dropped, 1)
+	if !s.reported {
+		// Dropped messages are reported at the end of the interval
+		s.reported = true
+		start := s.start
+		time.AfterFunc(s.config.Interval-time.Since(start), func() {
+			s.expire(start)
+		})
+	}
+	return false
+}
+
+// expire logs numbers of dropped messages and starts a new interval
+// if the interval was not reset by incoming messages
+func (s *Sampler) expire(start time.Time) {
+	s.mutex.Lock()
+	var reports []string
+	if s.start.Equal(start) {
+		reports = s.reset()
+	}
+	s.mutex.Unlock()
+	s.report(reports)
+}
+
+// reset resets counters and returns reports of dropped messages, mutex must be locked
+func (s *Sampler) reset() []string {
+	var reports []string
+	for k, c := range s.counters {
+		if c.dropped > 0 {
+			level := k.level.String()
+			if k.component != "" {
+				level = k.component + " " + level
+			}
+			reports = append(reports, fmt.Sprintf("Sampling dropped %d %s messages: %s", c.dropped, level, k.message))
+		}
+	}
+	s.counters = make(map[key]*counter)
+	s.start = time.Now()
+	s.reported = false
+	return reports
+}
+
+// report logs numbers of dropped messages, it is called without locked mutex
+// because the logger could log its messages through the sampler
+func (s *Sampler) report(reports []string) {
+	for _, report := range reports {
+		s.log.Warn(report)
+	}
+}
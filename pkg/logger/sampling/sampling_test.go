@@ -0,0 +1,134 @@
+package sampling
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+func TestSampling(t *testing.T) {
+	log := loggertest.New()
+	sampler := New(log, Config{Interval: time.Hour, First: 3, Thereafter: 10})
+	for i := 0; i < 33; i++ {
+		sampler.Error("repeated message")
+		sampler.Errorf("repeated message %d", i)
+	}
+	sampler.Info("other message")
+	// 3 first messages and every 10th of 30 next messages
+	if count := log.Count(logger.LevelError); count != 2*(3+3) {
+		t.Error("Expected", 2*(3+3), "messages, got", count)
+	}
+	if !log.Contains(logger.LevelInfo, "other message") {
+		t.Error("Expected message which is not repeated")
+	}
+	if caller := log.Entries()[0].Caller.FuncName(); caller != "sampling.TestSampling" {
+		t.Error("Expected caller of the sampler, got", caller)
+	}
+	if dropped := sampler.Dropped(); dropped != 2*27 {
+		t.Error("Expected", 2*27, "dropped messages, got", dropped)
+	}
+}
+
+func TestSamplingLevels(t *testing.T) {
+	log := loggertest.New()
+	log.SetLevel(logger.LevelInfo)
+	sampler := New(log, Config{Interval: time.Hour, First: 1})
+	for i := 0; i < 5; i++ {
+		sampler.Debug("debug message")
+		sampler.Warn("message")
+		sampler.Error("message")
+		sampler.Fatal("fatal message")
+	}
+	if count := log.Count(logger.LevelWarn); count != 1 {
+		t.Error("Expected 1 warn message, got", count)
+	}
+	if count := log.Count(logger.LevelError); count != 1 {
+		t.Error("Expected 1 error message, got", count)
+	}
+	if count := log.Count(logger.LevelFatal); count != 5 {
+		t.Error("Expected all of 5 fatal messages, got", count)
+	}
+	if dropped := sampler.Dropped(); dropped != 8 {
+		t.Error("Expected 8 dropped messages, got", dropped)
+	}
+	sampler.SetLevel(logger.LevelDebug)
+	if log.Level() != logger.LevelDebug || sampler.Level() != logger.LevelDebug {
+		t.Error("Expected changed log level, got", log.Level())
+	}
+}
+
+func TestSamplingReport(t *testing.T) {
+	log := loggertest.New()
+	sampler := New(log, Config{Interval: 20 * time.Millisecond, First: 1})
+	for i := 0; i < 5; i++ {
+		sampler.Errorf("message %d", i)
+	}
+	want := fmt.Sprintf("Sampling dropped %d %s messages: %s", 4, logger.LevelError, "message %d")
+	for i := 0; i < 100 && !log.Contains(logger.LevelWarn, want); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !log.Contains(logger.LevelWarn, want) {
+		t.Error("Expected report about dropped messages, got", log.Entries())
+	}
+	// Counters are reset in the next interval
+	sampler.Errorf("message %d", 5)
+	if count := log.Count(logger.LevelError); count != 2 {
+		t.Error("Expected 2 error messages, got", count)
+	}
+}
+
+func TestSamplingDefaults(t *testing.T) {
+	log := loggertest.New()
+	// Only the interval is configured
+	sampler := New(log, Config{Interval: time.Hour})
+	for i := 0; i < 3; i++ {
+		sampler.Error("repeated message")
+	}
+	if count := log.Count(logger.LevelError); count != 1 {
+		t.Error("Expected the first of repeated messages, got", count)
+	}
+	if dropped := sampler.Dropped(); dropped != 2 {
+		t.Error("Expected 2 dropped messages, got", dropped)
+	}
+}
+
+func TestSamplingWithFields(t *testing.T) {
+	log := loggertest.New()
+	sampler := New(log, Config{Interval: time.Hour, First: 1})
+	for i := 0; i < 3; i++ {
+		logger.WithFields(sampler, logger.Fields{"request_id": i}).Error("repeated message")
+	}
+	entries := log.Entries()
+	if len(entries) != 1 || entries[0].Fields["request_id"] != 0 || entries[0].Message != "repeated message" {
+		t.Error("Expected the first message with its fields, got", entries)
+	}
+	if dropped := sampler.Dropped(); dropped != 2 {
+		t.Error("Expected 2 dropped messages of the sampler, got", dropped)
+	}
+}
+
+func TestSamplingNamed(t *testing.T) {
+	log := loggertest.New()
+	sampler := New(log, Config{Interval: time.Hour, First: 1})
+	for i := 0; i < 5; i++ {
+		logger.Named(sampler, "http").Error("repeated message")
+		logger.Named(sampler, "db").Error("repeated message")
+	}
+	// The first message of every component
+	if count := log.Count(logger.LevelError); count != 2 {
+		t.Error("Expected 2 messages, got", count)
+	}
+	if dropped := sampler.Dropped(); dropped != 8 {
+		t.Error("Expected 8 dropped messages of components, got", dropped)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the client
+const (
+	DefaultQueueSize    = 100
+	DefaultTimeout      = 5 * time.Second
+	DefaultFlushTimeout = 5 * time.Second
+	// DefaultRetryAfter is used if the rate limit response has no Retry-After header
+	DefaultRetryAfter = time.Minute
+)
+
+// ErrFlushTimeout is returned if queued events were not sent in time
+var ErrFlushTimeout = errors.New("timeout of flush of error reports")
+
+// Config contains settings of error reporting
+type Config struct {
+	// DSN of the Sentry project
+	DSN string
+	// Release of the service
+	Release string
+	// Environment of the service, e.g. "production"
+	Environment string
+	// ServerName is a name of the host or the pod
+	ServerName string
+	// QueueSize limits events which are waiting for sending, next events are dropped
+	QueueSize int
+	// Timeout of sending of an event
+	Timeout time.Duration
+	// FlushTimeout limits waiting of Flush
+	FlushTimeout time.Duration
+}
+
+// item is a queued event or a flush marker
+type item struct {
+	event *Event
+	done  chan struct{}
+}
+
+// Client sends events asynchronously using the envelope protocol
+type Client struct {
+	config  Config
+	dsn     *DSN
+	http    *http.Client
+	queue   chan item
+	dropped uint64
+
+	// events are not sent until this time after rate limit responses,
+	// it is used by the sending goroutine only
+	limited time.Time
+}
+
+// NewClient returns client of the project of the DSN
+func NewClient(config Config) (*Client, error) {
+	dsn, err := ParseDSN(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.FlushTimeout <= 0 {
+		config.FlushTimeout = DefaultFlushTimeout
+	}
+	if config.ServerName == "" {
+		config.ServerName, _ = os.Hostname()
+	}
+	c := &Client{
+		config: config,
+		dsn:    dsn,
+		http:   &http.Client{Timeout: config.Timeout},
+		queue:  make(chan item, config.QueueSize),
+	}
+	go c.run()
+	return c, nil
+}
+
+// Capture queues the event and returns its ID, the event is dropped
+// if the queue is full
+func (c *Client) Capture(event *Event) string {
+	if event.EventID == "" {
+		event.EventID = newEventID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	event.Platform = "go"
+	if event.Release == "" {
+		event.Release = c.config.Release
+	}
+	if event.Environment == "" {
+		event.Environment = c.config.Environment
+	}
+	if event.ServerName == "" {
+		event.ServerName = c.config.ServerName
+	}
+	select {
+	case c.queue <- item{event: event}:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+	}
+	return event.EventID
+}
+
+// Dropped returns a total number of dropped events
+func (c *Client) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// Flush waits until queued events are sent or flush timeout expires
+func (c *Client) Flush() error {
+	timer := time.NewTimer(c.config.FlushTimeout)
+	defer timer.Stop()
+	done := make(chan struct{})
+	select {
+	case c.queue <- item{done: done}:
+	case <-timer.C:
+		return ErrFlushTimeout
+	}
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return ErrFlushTimeout
+	}
+}
+
+func (c *Client) run() {
+	for msg := range c.queue {
+		if msg.done != nil {
+			close(msg.done)
+			continue
+		}
+		if err := c.send(msg.event); err != nil {
+			// The logger could not be used, its errors are reported here
+			fmt.Fprintf(os.Stderr, "Failed to send error report, %v\n", err)
+		}
+	}
+}
+
+// send posts the event in an envelope
+func (c *Client) send(event *Event) error {
+	if time.Now().Before(c.limited) {
+		atomic.AddUint64(&c.dropped, 1)
+		return nil
+	}
+	body, err := envelope(c.dsn, event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.dsn.EnvelopeURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.dsn.AuthHeader("k8sapp/"+c.config.Release))
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		c.limited = time.Now().Add(retryAfter(resp.Header.Get("Retry-After")))
+		return fmt.Errorf("rate limit of error reports, event %s is dropped", event.EventID)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected response of error reports: %s", resp.Status)
+	}
+	return nil
+}
+
+func retryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultRetryAfter
+}
+
+// envelope encodes the event with envelope and item headers,
+// items are separated by new lines
+func envelope(dsn *DSN, event *Event) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(struct {
+		EventID string    `json:"event_id"`
+		SentAt  time.Time `json:"sent_at"`
+		DSN     string    `json:"dsn"`
+	}{event.EventID, time.Now().UTC(), dsn.String()})
+	if err != nil {
+		return nil, err
+	}
+	itemHeader, err := json.Marshal(struct {
+		Type        string `json:"type"`
+		Length      int    `json:"length"`
+		ContentType string `json:"content_type"`
+	}{"event", len(payload), "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(itemHeader)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sentry
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DSN contains parts of the data source name of a Sentry project:
+// {scheme}://{key}@{host}{path}/{project}
+type DSN struct {
+	Scheme    string
+	PublicKey string
+	Host      string
+	Path      string
+	ProjectID string
+
+	raw string
+}
+
+// ParseDSN parses the data source name of a Sentry project
+func ParseDSN(dsn string) (*DSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid sentry DSN scheme: %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sentry DSN does not contain a public key")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("sentry DSN does not contain a host")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 || path[i+1:] == "" {
+		return nil, fmt.Errorf("sentry DSN does not contain a project ID")
+	}
+	return &DSN{
+		Scheme:    u.Scheme,
+		PublicKey: u.User.Username(),
+		Host:      u.Host,
+		Path:      path[:i],
+		ProjectID: path[i+1:],
+		raw:       dsn,
+	}, nil
+}
+
+// String returns the data source name
+func (d *DSN) String() string {
+	return d.raw
+}
+
+// EnvelopeURL returns URL of the envelope endpoint of the project
+func (d *DSN) EnvelopeURL() string {
+	return d.Scheme + "://" + d.Host + d.Path + "/api/" + d.ProjectID + "/envelope/"
+}
+
+// AuthHeader returns value of X-Sentry-Auth header of requests
+func (d *DSN) AuthHeader(client string) string {
+	return "Sentry sentry_version=7, sentry_key=" + d.PublicKey + ", sentry_client=" + client
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sentry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Event is an error event of the Sentry protocol
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Request     *Request               `json:"request,omitempty"`
+	Exception   *Exceptions            `json:"exception,omitempty"`
+}
+
+// Request contains data of the HTTP request of the event
+type Request struct {
+	URL    string `json:"url,omitempty"`
+	Method string `json:"method,omitempty"`
+}
+
+// Exceptions contains errors and panics of the event
+type Exceptions struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception describes an error or a panic
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace contains frames of the call stack, the oldest frame is the first
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a frame of the call stack
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Frames of these packages are not reported
+const (
+	modulePath    = "github.com/takama/k8sapp"
+	loggerPackage = modulePath + "/pkg/logger"
+)
+
+// variable parts of messages which are ignored by fingerprints
+var variable = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b|\d+`)
+
+// Fingerprint groups events by the component and the message template,
+// numbers and identifiers are ignored if the template is unknown
+func Fingerprint(component, template string, formatted bool) []string {
+	if !formatted {
+		template = variable.ReplaceAllString(template, "<n>")
+	}
+	if component == "" {
+		return []string{template}
+	}
+	return []string{component, template}
+}
+
+// newEventID returns 32 hex digits of a random UUID without dashes
+func newEventID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return strings.Repeat("0", 32)
+	}
+	return hex.EncodeToString(id)
+}
+
+// stacktrace returns frames of the caller excluding the runtime and the loggers
+func stacktrace(skip int) *Stacktrace {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	var list []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !internal(frame) {
+			function, module := splitFunction(frame.Function)
+			list = append(list, Frame{
+				Function: function,
+				Module:   module,
+				Filename: shortFile(frame.File),
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    strings.HasPrefix(module, modulePath),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	// Sentry expects the oldest frame first
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return &Stacktrace{Frames: list}
+}
+
+func internal(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "runtime.") {
+		return true
+	}
+	return strings.HasPrefix(frame.Function, loggerPackage) &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// splitFunction splits "github.com/a/b/pkg.(*Type).Method" into function and package
+func splitFunction(name string) (function, module string) {
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[slash+dot+2:], name[:slash+dot+1]
+	}
+	return name, ""
+}
+
+func shortFile(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		if j := strings.LastIndex(path[:i], "/"); j >= 0 {
+			return path[j+1:]
+		}
+	}
+	return path
+}
+
+// extra converts values of fields which could not be encoded into JSON
+func extra(fields logger.Fields) map[string]interface{} {
+	values := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		switch v := value.(type) {
+		case nil, string, bool, int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64, float32, float64:
+			values[key] = v
+		case error:
+			values[key] = v.Error()
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,202 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sentry
+
+import (
+	"fmt"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// Reporter sends error and fatal messages to a Sentry-compatible endpoint
+// and passes all messages to the logger
+type Reporter struct {
+	log    logger.Logger
+	client *Client
+	name   string
+	fields logger.Fields
+}
+
+// New returns logger which reports errors by the client
+func New(log logger.Logger, client *Client) *Reporter {
+	return &Reporter{log: log, client: client}
+}
+
+// WithFields returns logger which adds the fields into every message,
+// the fields are reported as tags, request data and extra data
+func (r *Reporter) WithFields(fields logger.Fields) logger.Logger {
+	merged := make(logger.Fields, len(r.fields)+len(fields))
+	for key, value := range r.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Reporter{log: logger.WithFields(r.log, fields), client: r.client, name: r.name, fields: merged}
+}
+
+// Named returns logger of the component which reports errors by the same client
+func (r *Reporter) Named(name string) logger.Logger {
+	return &Reporter{
+		log:    logger.Named(r.log, name),
+		client: r.client,
+		name:   logger.JoinName(r.name, name),
+		fields: r.fields,
+	}
+}
+
+// Unwrap returns the wrapped logger
+func (r *Reporter) Unwrap() logger.Logger {
+	return r.log
+}
+
+// Client returns the client which sends events
+func (r *Reporter) Client() *Client {
+	return r.client
+}
+
+// Level returns the current log level
+func (r *Reporter) Level() logger.Level {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		return leveler.Level()
+	}
+	return logger.LevelDebug
+}
+
+// SetLevel changes the log level
+func (r *Reporter) SetLevel(level logger.Level) {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (r *Reporter) Debug(v ...interface{}) {
+	r.log.Debug(v...)
+}
+
+// Debug logs a debug message with format
+func (r *Reporter) Debugf(format string, v ...interface{}) {
+	r.log.Debugf(format, v...)
+}
+
+// Info logs a info message
+func (r *Reporter) Info(v ...interface{}) {
+	r.log.Info(v...)
+}
+
+// Info logs a info message with format
+func (r *Reporter) Infof(format string, v ...interface{}) {
+	r.log.Infof(format, v...)
+}
+
+// Warn logs a warning message.
+func (r *Reporter) Warn(v ...interface{}) {
+	r.log.Warn(v...)
+}
+
+// Warn logs a warning message with format.
+func (r *Reporter) Warnf(format string, v ...interface{}) {
+	r.log.Warnf(format, v...)
+}
+
+// Error logs an error message
+func (r *Reporter) Error(v ...interface{}) {
+	if logger.LevelError >= r.Level() {
+		msg := fmt.Sprint(v...)
+		r.capture(logger.LevelError, msg, msg, false, v)
+	}
+	r.log.Error(v...)
+}
+
+// Error logs an error message with format
+func (r *Reporter) Errorf(format string, v ...interface{}) {
+	if logger.LevelError >= r.Level() {
+		r.capture(logger.LevelError, fmt.Sprintf(format, v...), format, true, v)
+	}
+	r.log.Errorf(format, v...)
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1),
+// queued events are sent by logger.Flush before the exit
+// if the client is registered as a flusher
+func (r *Reporter) Fatal(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	r.capture(logger.LevelFatal, msg, msg, false, v)
+	r.log.Fatal(v...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (r *Reporter) Fatalf(format string, v ...interface{}) {
+	r.capture(logger.LevelFatal, fmt.Sprintf(format, v...), format, true, v)
+	r.log.Fatalf(format, v...)
+}
+
+// capture reports the message, errors of the values define type of the exception,
+// messages with logger.FieldPanic are reported as panics
+func (r *Reporter) capture(level logger.Level, msg, template string, formatted bool, v []interface{}) {
+	event := &Event{
+		Level:       level.String(),
+		Logger:      r.name,
+		Message:     msg,
+		Fingerprint: Fingerprint(r.name, template, formatted),
+		Tags:        make(map[string]string),
+	}
+	exception := Exception{Type: level.String(), Value: msg, Stacktrace: stacktrace(3)}
+	for _, value := range v {
+		if err, ok := value.(error); ok {
+			exception.Type = fmt.Sprintf("%T", err)
+			break
+		}
+	}
+	fields := make(logger.Fields, len(r.fields))
+	for key, value := range r.fields {
+		fields[key] = value
+	}
+	if p, ok := fields[logger.FieldPanic]; ok {
+		delete(fields, logger.FieldPanic)
+		exception.Type = "panic"
+		exception.Value = fmt.Sprint(p)
+		event.Level = logger.LevelFatal.String()
+		event.Fingerprint = Fingerprint(r.name, "panic: "+exception.Value, false)
+	}
+	event.Exception = &Exceptions{Values: []Exception{exception}}
+	if r.name != "" {
+		event.Tags[logger.FieldComponent] = r.name
+	}
+	delete(fields, logger.FieldComponent)
+	for _, key := range []string{logger.FieldRequestID, logger.FieldTraceID} {
+		if value, ok := fields[key]; ok {
+			event.Tags[key] = fmt.Sprint(value)
+			delete(fields, key)
+		}
+	}
+	path, _ := fields[logger.FieldPath].(string)
+	method, _ := fields[logger.FieldMethod].(string)
+	if path != "" || method != "" {
+		event.Request = &Request{URL: requestURL(fields, path), Method: method}
+	}
+	for _, key := range []string{logger.FieldPath, logger.FieldMethod, logger.FieldHost, logger.FieldScheme} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		event.Extra = extra(fields)
+	}
+	r.client.Capture(event)
+}
+
+// requestURL returns absolute URL of the request by its host and scheme,
+// the path is returned if the host is unknown
+func requestURL(fields logger.Fields, path string) string {
+	host, _ := fields[logger.FieldHost].(string)
+	if host == "" {
+		return path
+	}
+	scheme, _ := fields[logger.FieldScheme].(string)
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + host + path
+}
@@ -0,0 +1,224 @@
+package sentry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+// stub is a Sentry-compatible endpoint which collects events
+type stub struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	events []Event
+	auth   []string
+	status int
+}
+
+func newStub(t *testing.T) *stub {
+	s := &stub{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/envelope/" || r.Header.Get("Content-Type") != "application/x-sentry-envelope" {
+			t.Error("Unexpected request", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		scanner := bufio.NewScanner(r.Body)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		var header struct {
+			EventID string `json:"event_id"`
+		}
+		var item struct {
+			Type   string `json:"type"`
+			Length int    `json:"length"`
+		}
+		var event Event
+		if len(lines) != 3 || json.Unmarshal([]byte(lines[0]), &header) != nil ||
+			json.Unmarshal([]byte(lines[1]), &item) != nil || json.Unmarshal([]byte(lines[2]), &event) != nil {
+			t.Error("Invalid envelope", lines)
+		}
+		if item.Type != "event" || item.Length != len(lines[2]) || header.EventID != event.EventID {
+			t.Error("Invalid headers of envelope", lines[0], lines[1])
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.events = append(s.events, event)
+		s.auth = append(s.auth, r.Header.Get("X-Sentry-Auth"))
+		if s.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "60")
+		}
+		w.WriteHeader(s.status)
+	}))
+	return s
+}
+
+func (s *stub) DSN() string {
+	return strings.Replace(s.URL, "://", "://public@", 1) + "/42"
+}
+
+func (s *stub) Events() []Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func TestParseDSN(t *testing.T) {
+	dsn, err := ParseDSN("https://key@sentry.example.com/prefix/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsn.PublicKey != "key" || dsn.ProjectID != "42" ||
+		dsn.EnvelopeURL() != "https://sentry.example.com/prefix/api/42/envelope/" {
+		t.Error("Unexpected parts of DSN", dsn.PublicKey, dsn.ProjectID, dsn.EnvelopeURL())
+	}
+	for _, invalid := range []string{
+		"", "ftp://key@example.com/1", "https://example.com/1", "https://key@example.com/",
+	} {
+		if _, err := ParseDSN(invalid); err == nil {
+			t.Error("Expected error of DSN", invalid)
+		}
+	}
+}
+
+func TestReporter(t *testing.T) {
+	s := newStub(t)
+	defer s.Close()
+	client, err := NewClient(Config{DSN: s.DSN(), Release: "1.2.3", Environment: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := loggertest.New()
+	reporter := New(log, client)
+	reporter.Info("info message")
+	request := logger.WithFields(logger.Named(reporter, "http"), logger.Fields{
+		logger.FieldRequestID: "abc", logger.FieldPath: "/users/1", logger.FieldMethod: "GET",
+		logger.FieldHost: "example.com", logger.FieldScheme: "https", "user": 1,
+	})
+	request.Errorf("can not find user %d", 1)
+	request.Error(errors.New("connection refused"), " while reading 42")
+	reporter.SetLevel(logger.LevelFatal)
+	reporter.Error("filtered error")
+	reporter.Fatal("can not start")
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if log.Len() != 4 {
+		t.Error("Expected all messages in the logger, got", log.Entries())
+	}
+	events := s.Events()
+	if len(events) != 3 {
+		t.Fatal("Expected 3 events, got", events)
+	}
+	event := events[0]
+	if event.Level != "error" || event.Message != "can not find user 1" || event.Release != "1.2.3" ||
+		event.Environment != "test" || event.Platform != "go" || len(event.EventID) != 32 {
+		t.Error("Unexpected event", event)
+	}
+	if !reflect.DeepEqual(event.Fingerprint, []string{"http", "can not find user %d"}) {
+		t.Error("Expected fingerprint of the template, got", event.Fingerprint)
+	}
+	if event.Request == nil || event.Request.URL != "https://example.com/users/1" || event.Request.Method != "GET" {
+		t.Error("Expected request data, got", event.Request)
+	}
+	if event.Tags[logger.FieldRequestID] != "abc" || event.Tags[logger.FieldComponent] != "http" ||
+		event.Extra["user"] != float64(1) || len(event.Extra) != 1 {
+		t.Error("Expected tags and extra data, got", event.Tags, event.Extra)
+	}
+	frames := event.Exception.Values[0].Stacktrace.Frames
+	if len(frames) == 0 || frames[len(frames)-1].Function != "TestReporter" {
+		t.Error("Expected stack trace of the caller, got", frames)
+	}
+	event = events[1]
+	if event.Exception.Values[0].Type != "*errors.errorString" ||
+		!reflect.DeepEqual(event.Fingerprint, []string{"http", "connection refused while reading <n>"}) {
+		t.Error("Expected exception of the error, got", event.Exception.Values[0].Type, event.Fingerprint)
+	}
+	if events[2].Level != "fatal" || events[2].Message != "can not start" {
+		t.Error("Expected fatal event, got", events[2])
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if auth := s.auth[0]; !strings.Contains(auth, "sentry_key=public") {
+		t.Error("Expected public key in auth header, got", auth)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	s := newStub(t)
+	defer s.Close()
+	client, err := NewClient(Config{DSN: s.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter := New(loggertest.New(), client)
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.WithFields(reporter, logger.Fields{logger.FieldPanic: p}).Errorf("panic: %v", p)
+			}
+		}()
+		var values map[string]int
+		values["key"] = 1
+	}()
+	client.Flush()
+	events := s.Events()
+	if len(events) != 1 {
+		t.Fatal("Expected panic event, got", events)
+	}
+	exception := events[0].Exception.Values[0]
+	if exception.Type != "panic" || !strings.Contains(exception.Value, "nil map") || events[0].Extra != nil {
+		t.Error("Expected panic exception, got", exception, events[0].Extra)
+	}
+	found := false
+	for _, frame := range exception.Stacktrace.Frames {
+		if strings.HasPrefix(frame.Function, "TestPanic") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected frames of the panic, got", exception.Stacktrace.Frames)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := newStub(t)
+	defer s.Close()
+	s.mutex.Lock()
+	s.status = http.StatusTooManyRequests
+	s.mutex.Unlock()
+	client, err := NewClient(Config{DSN: s.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter := New(loggertest.New(), client)
+	reporter.Error("first error")
+	reporter.Error("second error")
+	client.Flush()
+	if events := s.Events(); len(events) != 1 || client.Dropped() != 1 {
+		t.Error("Expected dropped events after rate limit, got", len(events), client.Dropped())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	got := Fingerprint("", "user 12 not found, request deadbeef01", false)
+	if !reflect.DeepEqual(got, []string{"user <n> not found, request <n>"}) {
+		t.Error("Expected fingerprint without variable parts, got", got)
+	}
+}
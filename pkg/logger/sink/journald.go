@@ -0,0 +1,125 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// JournaldAddress is a default socket of the journald native protocol
+const JournaldAddress = "/run/systemd/journal/socket"
+
+// NewJournald creates logger which sends messages with structured fields
+// into journald socket by its native protocol
+func NewJournald(cfg *logger.Config) (logger.Logger, error) {
+	network, address := cfg.SinkNetwork, cfg.SinkAddress
+	if network == "" {
+		network = "unixgram"
+	}
+	if network != "unixgram" {
+		return nil, fmt.Errorf("unsupported network of journald: %s", network)
+	}
+	if address == "" {
+		address = JournaldAddress
+	}
+	c, err := dial(network, address, false)
+	if err != nil {
+		return nil, err
+	}
+	identifier := appName(cfg)
+	return newLogger(cfg, c, func(e *entry) []byte {
+		return encodeJournald(e, identifier)
+	}), nil
+}
+
+// encodeJournald returns datagram of the native protocol, fields
+// are "KEY=value" lines, values with new lines are encoded with their size
+func encodeJournald(e *entry, identifier string) []byte {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(severity(e.level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", identifier)
+	if !e.time.IsZero() {
+		writeJournalField(&b, "SYSLOG_TIMESTAMP", e.time.Format(syslogTimeFormat))
+	}
+	if e.found {
+		writeJournalField(&b, "CODE_FILE", e.caller.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(e.caller.Line))
+		writeJournalField(&b, "CODE_FUNC", e.caller.Function)
+	}
+	if e.stack != "" {
+		writeJournalField(&b, "STACK", e.stack)
+	}
+	keys := make([]string, 0, len(e.fields))
+	for key := range e.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeJournalField(&b, journalFieldName(key), fmt.Sprint(e.fields[key]))
+	}
+	return b.Bytes()
+}
+
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b.Write(size[:])
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFields are written by the logger, fields of messages
+// with the same names are prefixed to not override them
+var journalFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"SYSLOG_TIMESTAMP":  true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+	"STACK":             true,
+}
+
+// journalFieldName returns valid name of the journal field: upper case letters,
+// digits and underscores, it must not start with underscore or digit
+// and must not be a name of the fields which are written by the logger
+func journalFieldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	} else if journalFields[name] {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
@@ -0,0 +1,266 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package sink implements loggers which send messages into syslog or journald sockets
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// ErrClosed is returned by writes after Close, messages are written into stderr then
+var ErrClosed = errors.New("log sink is closed")
+
+// entry contains a message and its attributes for encoders
+type entry struct {
+	level   logger.Level
+	time    time.Time
+	message string
+	fields  logger.Fields
+	caller  logger.Caller
+	found   bool
+	stack   string
+}
+
+// encoder returns a datagram or stream record of the entry
+type encoder func(e *entry) []byte
+
+// sinkLogger implements the Logger, Leveler, FieldLogger and NamedLogger interfaces
+type sinkLogger struct {
+	*logger.AtomicLevel
+	conn   *conn
+	encode encoder
+
+	time       bool
+	utc        bool
+	caller     bool
+	callerSkip int
+	stacktrace bool
+
+	// name of the component, levels of components and fields of messages
+	name   string
+	levels logger.Levels
+	fields logger.Fields
+}
+
+func newLogger(cfg *logger.Config, c *conn, encode encoder) *sinkLogger {
+	fields := make(logger.Fields, len(cfg.Fields))
+	for key, value := range cfg.Fields {
+		fields[key] = value
+	}
+	return &sinkLogger{
+		AtomicLevel: logger.NewAtomicLevel(cfg.Level),
+		conn:        c,
+		encode:      encode,
+		time:        cfg.Time,
+		utc:         cfg.UTC,
+		caller:      cfg.Caller,
+		callerSkip:  cfg.CallerSkip,
+		stacktrace:  cfg.Stacktrace,
+		levels:      cfg.Levels,
+		fields:      fields,
+	}
+}
+
+// appName returns name of the application for messages
+func appName(cfg *logger.Config) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// Named returns logger of the component, its name is added into messages
+func (l *sinkLogger) Named(name string) logger.Logger {
+	name = logger.JoinName(l.name, name)
+	clone := l.with(logger.Fields{logger.FieldComponent: name})
+	clone.name = name
+	clone.AtomicLevel = l.levels.Level(name, l.AtomicLevel)
+	return clone
+}
+
+// WithFields returns logger which adds the fields into every message
+func (l *sinkLogger) WithFields(fields logger.Fields) logger.Logger {
+	return l.with(fields)
+}
+
+func (l *sinkLogger) with(fields logger.Fields) *sinkLogger {
+	clone := *l
+	clone.fields = make(logger.Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		clone.fields[key] = value
+	}
+	for key, value := range fields {
+		clone.fields[key] = value
+	}
+	return &clone
+}
+
+// Debug logs a debug message
+func (l *sinkLogger) Debug(v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprint(v...))
+	}
+}
+
+// Debug logs a debug message with format
+func (l *sinkLogger) Debugf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+// Info logs a info message
+func (l *sinkLogger) Info(v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprint(v...))
+	}
+}
+
+// Info logs a info message with format
+func (l *sinkLogger) Infof(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprintf(format, v...))
+	}
+}
+
+// Warn logs a warning message.
+func (l *sinkLogger) Warn(v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprint(v...))
+	}
+}
+
+// Warn logs a warning message with format.
+func (l *sinkLogger) Warnf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprintf(format, v...))
+	}
+}
+
+// Error logs an error message
+func (l *sinkLogger) Error(v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprint(v...))
+	}
+}
+
+// Error logs an error message with format
+func (l *sinkLogger) Errorf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprintf(format, v...))
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (l *sinkLogger) Fatal(v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprint(v...))
+	}
+	logger.Exit(1)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (l *sinkLogger) Fatalf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprintf(format, v...))
+	}
+	logger.Exit(1)
+}
+
+func (l *sinkLogger) log(level logger.Level, msg string) {
+	e := &entry{level: level, message: msg, fields: l.fields}
+	if l.time {
+		e.time = time.Now()
+		if l.utc {
+			e.time = e.time.UTC()
+		}
+	}
+	if l.caller {
+		e.caller, e.found = logger.FindCaller(l.callerSkip)
+	}
+	if l.stacktrace && level >= logger.LevelError {
+		e.stack = logger.Stack(l.callerSkip)
+	}
+	if err := l.conn.write(l.encode(e)); err != nil {
+		// Messages are not lost if the sink is unavailable
+		fmt.Fprintf(os.Stderr, "Failed to write to %s: %v\n%s\n", l.conn.address, err, msg)
+	}
+}
+
+// conn writes records into the socket, it is reconnected after failures
+type conn struct {
+	network string
+	address string
+	// framing of records is required by stream sockets
+	framing bool
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+func dial(network, address string, framing bool) (*conn, error) {
+	c := &conn{network: network, address: address, framing: framing}
+	var err error
+	c.conn, err = net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) write(record []byte) error {
+	if c.framing {
+		// Octet counting framing, RFC 6587
+		record = append([]byte(strconv.Itoa(len(record))+" "), record...)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	var err error
+	// The second attempt is made with a new connection
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if c.conn, err = net.Dial(c.network, c.address); err != nil {
+				c.conn = nil
+				continue
+			}
+		}
+		if _, err = c.conn.Write(record); err == nil {
+			return nil
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
+
+// Close closes connection of the logger, it is not reconnected by later messages
+func Close(log logger.Logger) error {
+	l, ok := log.(*sinkLogger)
+	if !ok {
+		return nil
+	}
+	l.conn.mutex.Lock()
+	defer l.conn.mutex.Unlock()
+	l.conn.closed = true
+	if l.conn.conn == nil {
+		return nil
+	}
+	err := l.conn.conn.Close()
+	l.conn.conn = nil
+	return err
+}
@@ -0,0 +1,265 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+// socketPath returns short path of unix socket in a temporary directory
+func socketPath(t *testing.T, name string) string {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, name)
+}
+
+func receive(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal("Expected message from the socket, got", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	log, err := NewSyslog(&logger.Config{
+		Level:       logger.LevelInfo,
+		Fields:      logger.Fields{"service": "test"},
+		Time:        true,
+		UTC:         true,
+		Caller:      true,
+		SinkNetwork: "udp",
+		SinkAddress: conn.LocalAddr().String(),
+		Name:        "k8sapp",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(log)
+	log.Debug("hidden message")
+	logger.WithFields(log, logger.Fields{"quote": `a "b" [c]`}).Warnf("%s message", "warn")
+	msg := receive(t, conn)
+	pattern := `^<12>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z \S+ k8sapp ` + strconv.Itoa(os.Getpid()) +
+		` - \[fields@32473 caller="sink/sink_test.go:\d+" func="sink.TestSyslogUDP"` +
+		` quote="a \\"b\\" \[c\\]" service="test"\] warn message$`
+	if !regexp.MustCompile(pattern).MatchString(msg) {
+		t.Errorf("invalid syslog message:\ngot:  %v\nwant: %v", msg, pattern)
+	}
+}
+
+func TestSyslogUnixgram(t *testing.T) {
+	path := socketPath(t, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	log, err := NewSyslog(&logger.Config{SinkAddress: path, Name: "k8s app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(log)
+	log.Error("error message")
+	want := " k8sapp " + strconv.Itoa(os.Getpid()) + " - - error message"
+	if msg := receive(t, conn); !strings.HasPrefix(msg, "<11>1 - ") || !strings.HasSuffix(msg, want) {
+		t.Errorf("invalid syslog message:\ngot:  %v\nwant: <11>1 - HOSTNAME%v", msg, want)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	records := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			size, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			record := make([]byte, n)
+			if _, err := reader.Read(record); err != nil {
+				return
+			}
+			records <- string(record)
+		}
+	}()
+	log, err := NewSyslog(&logger.Config{SinkNetwork: "tcp", SinkAddress: listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(log)
+	log.Info("first message")
+	log.Fatal("second message")
+	for _, want := range []string{"<14>1 ", "<10>1 "} {
+		select {
+		case record := <-records:
+			if !strings.HasPrefix(record, want) {
+				t.Errorf("invalid framed record:\ngot:  %v\nwant: %v...", record, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected framed record")
+		}
+	}
+}
+
+func TestSyslogErrors(t *testing.T) {
+	if _, err := NewSyslog(&logger.Config{SinkNetwork: "ip"}); err == nil {
+		t.Error("Expected error of unsupported network")
+	}
+	if _, err := NewSyslog(&logger.Config{SinkAddress: socketPath(t, "missing")}); err == nil {
+		t.Error("Expected error of missing socket")
+	}
+}
+
+// parseJournal returns fields of the native protocol datagram
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.Fatal("Expected new line in the datagram")
+		}
+		line := data[:i]
+		if j := bytes.IndexByte(line, '='); j >= 0 {
+			fields[string(line[:j])] = string(line[j+1:])
+			data = data[i+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[i+1 : i+9])
+		fields[string(line)] = string(data[i+9 : i+9+int(size)])
+		data = data[i+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestJournald(t *testing.T) {
+	path := socketPath(t, "socket")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	log, err := NewJournald(&logger.Config{
+		Levels:      logger.Levels{"db": logger.LevelError},
+		Caller:      true,
+		Stacktrace:  true,
+		SinkAddress: path,
+		Name:        "k8sapp",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(log)
+	db := logger.Named(log, "db")
+	db.Warn("hidden message")
+	logger.WithFields(db, logger.Fields{
+		"request-id": "1", "_trusted": "no", "message": "field", "priority": 7, "syslog_identifier": "user",
+	}).Error("multi\nline")
+	fields := parseJournal(t, []byte(receive(t, conn)))
+	want := map[string]string{
+		"MESSAGE":             "multi\nline",
+		"PRIORITY":            "3",
+		"SYSLOG_IDENTIFIER":   "k8sapp",
+		"COMPONENT":           "db",
+		"REQUEST_ID":          "1",
+		"F_TRUSTED":           "no",
+		"F_MESSAGE":           "field",
+		"F_PRIORITY":          "7",
+		"F_SYSLOG_IDENTIFIER": "user",
+		"CODE_FUNC":           "github.com/takama/k8sapp/pkg/logger/sink.TestJournald",
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("invalid %s field:\ngot:  %q\nwant: %q", key, fields[key], value)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "sink_test.go") || !strings.Contains(fields["STACK"], "TestJournald") {
+		t.Error("Expected caller and stack of the message, got", fields)
+	}
+	if _, err := NewJournald(&logger.Config{SinkNetwork: "udp"}); err == nil {
+		t.Error("Expected error of unsupported network")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	path := socketPath(t, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := NewSyslog(&logger.Config{SinkAddress: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(log)
+	// Restart of the syslog daemon
+	conn.Close()
+	os.Remove(path)
+	if conn, err = net.ListenPacket("unixgram", path); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	log.Info("message after restart")
+	if msg := receive(t, conn); !strings.HasSuffix(msg, "message after restart") {
+		t.Error("Expected message after reconnect, got", msg)
+	}
+}
+
+func TestClose(t *testing.T) {
+	path := socketPath(t, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	log, err := NewSyslog(&logger.Config{SinkAddress: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Close(log); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.(*sinkLogger).conn.write([]byte("message after close")); err != ErrClosed {
+		t.Error("Expected", ErrClosed, "got", err)
+	}
+	if c := log.(*sinkLogger).conn.conn; c != nil {
+		t.Error("Expected closed connection without reconnect, got", c)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+const (
+	// SyslogAddress is a default socket of syslog
+	SyslogAddress = "/dev/log"
+	// FacilityUser is a syslog facility of user-level messages
+	FacilityUser = 1
+	// StructuredDataID contains fields of messages, 32473 is reserved for documentation
+	// by RFC 5612, it is used as the enterprise number of the SD-ID
+	StructuredDataID = "fields@32473"
+	// syslogTimeFormat defines RFC 3339 time with microseconds
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	// nilValue is used for unknown values of the header
+	nilValue = "-"
+)
+
+// NewSyslog creates logger which sends RFC 5424 messages into syslog socket,
+// stream sockets use octet counting framing of RFC 6587
+func NewSyslog(cfg *logger.Config) (logger.Logger, error) {
+	network, address := cfg.SinkNetwork, cfg.SinkAddress
+	if network == "" {
+		network = "unixgram"
+	}
+	if address == "" {
+		address = SyslogAddress
+	}
+	framing := false
+	switch network {
+	case "unixgram", "udp", "udp4", "udp6":
+	case "unix", "tcp", "tcp4", "tcp6":
+		framing = true
+	default:
+		return nil, fmt.Errorf("unsupported network of syslog: %s", network)
+	}
+	c, err := dial(network, address, framing)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = nilValue
+	}
+	header := " " + headerValue(hostname, 255) + " " + headerValue(appName(cfg), 48) +
+		" " + strconv.Itoa(os.Getpid()) + " " + nilValue + " "
+	return newLogger(cfg, c, func(e *entry) []byte {
+		return encodeSyslog(e, header)
+	}), nil
+}
+
+// encodeSyslog returns RFC 5424 message:
+// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func encodeSyslog(e *entry, header string) []byte {
+	var b bytes.Buffer
+	b.WriteString("<" + strconv.Itoa(FacilityUser*8+severity(e.level)) + ">1 ")
+	if e.time.IsZero() {
+		b.WriteString(nilValue)
+	} else {
+		b.WriteString(e.time.Format(syslogTimeFormat))
+	}
+	b.WriteString(header)
+	params := make(map[string]string, len(e.fields)+3)
+	for key, value := range e.fields {
+		params[key] = fmt.Sprint(value)
+	}
+	if e.found {
+		params[logger.FieldCaller] = e.caller.String()
+		params[logger.FieldFunction] = e.caller.FuncName()
+	}
+	if e.stack != "" {
+		params[logger.FieldStack] = e.stack
+	}
+	if len(params) == 0 {
+		b.WriteString(nilValue)
+	} else {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("[" + StructuredDataID)
+		for _, key := range keys {
+			b.WriteString(" " + paramName(key) + `="` + paramValue(params[key]) + `"`)
+		}
+		b.WriteString("]")
+	}
+	if e.message != "" {
+		b.WriteString(" " + e.message)
+	}
+	return b.Bytes()
+}
+
+// severity returns syslog severity of the level
+func severity(level logger.Level) int {
+	switch level {
+	case logger.LevelDebug:
+		return 7
+	case logger.LevelInfo:
+		return 6
+	case logger.LevelWarn:
+		return 4
+	case logger.LevelError:
+		return 3
+	case logger.LevelFatal:
+		return 2
+	default:
+		return 5
+	}
+}
+
+// headerValue returns printable ASCII value of the header field limited by size
+func headerValue(value string, size int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return -1
+		}
+		return r
+	}, value)
+	if len(value) > size {
+		value = value[:size]
+	}
+	if value == "" {
+		return nilValue
+	}
+	return value
+}
+
+// paramName returns valid name of the structured data parameter
+func paramName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// paramValue escapes '"', '\' and ']' characters of the parameter value
+func paramValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
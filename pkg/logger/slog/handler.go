@@ -0,0 +1,89 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// NewHandler returns slog.Handler which forwards records into the logger,
+// attributes of records are forwarded as fields with keys qualified by groups,
+// they are appended to messages if the logger does not support fields
+func NewHandler(log logger.Logger) slog.Handler {
+	return &handler{log: log}
+}
+
+// handler implements slog.Handler interface
+type handler struct {
+	log    logger.Logger
+	groups string
+}
+
+// Enabled uses level of the logger if it implements Leveler interface
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	if leveler, ok := h.log.(logger.Leveler); ok {
+		return loggerLevelConverter(level) >= leveler.Level()
+	}
+	return true
+}
+
+// Handle sends the record into the logger
+func (h *handler) Handle(_ context.Context, record slog.Record) error {
+	fields := make(logger.Fields, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.groups, a)
+		return true
+	})
+	log := logger.WithFields(h.log, fields)
+	switch loggerLevelConverter(record.Level) {
+	case logger.LevelDebug:
+		log.Debug(record.Message)
+	case logger.LevelInfo:
+		log.Info(record.Message)
+	case logger.LevelWarn:
+		log.Warn(record.Message)
+	default:
+		log.Error(record.Message)
+	}
+	return nil
+}
+
+// WithAttrs returns handler which adds the attributes to every message
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logger.Fields, len(attrs))
+	for _, a := range attrs {
+		addAttr(fields, h.groups, a)
+	}
+	return &handler{log: logger.WithFields(h.log, fields), groups: h.groups}
+}
+
+// WithGroup returns handler which qualifies keys of the next attributes by the group name
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{log: h.log, groups: h.groups + name + "."}
+}
+
+// addAttr adds the attribute into the fields, keys of groups are qualified by their names
+func addAttr(fields logger.Fields, prefix string, a slog.Attr) {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range value.Group() {
+			addAttr(fields, prefix, attr)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	fields[prefix+a.Key] = value.Any()
+}
@@ -0,0 +1,272 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// LevelFatal defines slog level of fatal messages
+const LevelFatal = slog.Level(12)
+
+// New creates "log/slog" logger with text or JSON handlers
+func New(cfg *logger.Config) logger.Logger {
+	if cfg.Out == nil {
+		cfg.Out = os.Stdout
+	}
+	if cfg.Err == nil {
+		cfg.Err = os.Stderr
+	}
+	options := &slog.HandlerOptions{
+		AddSource: cfg.Caller,
+		// Levels are filtered by slogLogger to make them changeable at runtime
+		Level: slog.Level(-8),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				if !cfg.Time {
+					return slog.Attr{}
+				}
+				if cfg.UTC {
+					a.Value = slog.TimeValue(a.Value.Time().UTC())
+				}
+			case slog.LevelKey:
+				if level, ok := a.Value.Any().(slog.Level); ok && level >= LevelFatal {
+					a.Value = slog.StringValue("FATAL")
+				}
+			}
+			return a
+		},
+	}
+	var out, err slog.Handler
+	if cfg.JSON {
+		out = slog.NewJSONHandler(cfg.Out, options)
+		err = slog.NewJSONHandler(cfg.Err, options)
+	} else {
+		out = slog.NewTextHandler(cfg.Out, options)
+		err = slog.NewTextHandler(cfg.Err, options)
+	}
+	return FromHandler(&levelRouter{out: out, err: err}, cfg)
+}
+
+// FromHandler creates logger which sends messages into any slog.Handler,
+// only level and default fields are used from the config
+func FromHandler(handler slog.Handler, cfg *logger.Config) logger.Logger {
+	if len(cfg.Fields) > 0 {
+		attrs := make([]slog.Attr, 0, len(cfg.Fields))
+		for key, value := range cfg.Fields {
+			attrs = append(attrs, slog.Any(key, value))
+		}
+		handler = handler.WithAttrs(attrs)
+	}
+	return &slogLogger{
+		AtomicLevel: logger.NewAtomicLevel(cfg.Level),
+		handler:     handler,
+		caller:      cfg.Caller,
+		callerSkip:  cfg.CallerSkip,
+		stacktrace:  cfg.Stacktrace,
+		levels:      cfg.Levels,
+	}
+}
+
+// slogLogger implements the Logger and Leveler interfaces
+type slogLogger struct {
+	*logger.AtomicLevel
+	handler    slog.Handler
+	caller     bool
+	callerSkip int
+	stacktrace bool
+	name       string
+	levels     logger.Levels
+}
+
+// Named returns logger of the component, its name is added into messages
+func (l *slogLogger) Named(name string) logger.Logger {
+	clone := *l
+	clone.name = logger.JoinName(l.name, name)
+	clone.AtomicLevel = l.levels.Level(clone.name, l.AtomicLevel)
+	clone.handler = l.handler.WithAttrs([]slog.Attr{slog.String(logger.FieldComponent, clone.name)})
+	return &clone
+}
+
+// WithFields returns logger which adds the fields into every message
+func (l *slogLogger) WithFields(fields logger.Fields) logger.Logger {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for key, value := range fields {
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	clone := *l
+	clone.handler = l.handler.WithAttrs(attrs)
+	return &clone
+}
+
+// Debug logs a debug message
+func (l *slogLogger) Debug(v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprint(v...))
+	}
+}
+
+// Debug logs a debug message with format
+func (l *slogLogger) Debugf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+// Info logs a info message
+func (l *slogLogger) Info(v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprint(v...))
+	}
+}
+
+// Info logs a info message with format
+func (l *slogLogger) Infof(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprintf(format, v...))
+	}
+}
+
+// Warn logs a warning message.
+func (l *slogLogger) Warn(v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprint(v...))
+	}
+}
+
+// Warn logs a warning message with format.
+func (l *slogLogger) Warnf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprintf(format, v...))
+	}
+}
+
+// Error logs an error message
+func (l *slogLogger) Error(v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprint(v...))
+	}
+}
+
+// Error logs an error message with format
+func (l *slogLogger) Errorf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprintf(format, v...))
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (l *slogLogger) Fatal(v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprint(v...))
+	}
+	logger.Exit(1)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (l *slogLogger) Fatalf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprintf(format, v...))
+	}
+	logger.Exit(1)
+}
+
+func (l *slogLogger) log(level logger.Level, msg string) {
+	ctx := context.Background()
+	slogLevel := slogLevelConverter(level)
+	if !l.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	var pc uintptr
+	if l.caller {
+		// Source of the record is reported by the handler
+		if caller, ok := logger.FindCaller(l.callerSkip); ok {
+			pc = caller.PC
+		}
+	} else {
+		var pcs [1]uintptr
+		// Skip runtime.Callers, log and the Logger method
+		runtime.Callers(3, pcs[:])
+		pc = pcs[0]
+	}
+	record := slog.NewRecord(time.Now(), slogLevel, msg, pc)
+	if l.stacktrace && level >= logger.LevelError {
+		record.AddAttrs(slog.String(logger.FieldStack, logger.Stack(l.callerSkip)))
+	}
+	if err := l.handler.Handle(ctx, record); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+// levelRouter sends records of levels debug, info, warn into out handler
+// and records of levels error, fatal into err handler
+type levelRouter struct {
+	out slog.Handler
+	err slog.Handler
+}
+
+func (r *levelRouter) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= slog.LevelError {
+		return r.err.Enabled(ctx, level)
+	}
+	return r.out.Enabled(ctx, level)
+}
+
+func (r *levelRouter) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		return r.err.Handle(ctx, record)
+	}
+	return r.out.Handle(ctx, record)
+}
+
+func (r *levelRouter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelRouter{out: r.out.WithAttrs(attrs), err: r.err.WithAttrs(attrs)}
+}
+
+func (r *levelRouter) WithGroup(name string) slog.Handler {
+	return &levelRouter{out: r.out.WithGroup(name), err: r.err.WithGroup(name)}
+}
+
+func slogLevelConverter(level logger.Level) slog.Level {
+	switch level {
+	case logger.LevelDebug:
+		return slog.LevelDebug
+	case logger.LevelInfo:
+		return slog.LevelInfo
+	case logger.LevelWarn:
+		return slog.LevelWarn
+	case logger.LevelError:
+		return slog.LevelError
+	case logger.LevelFatal:
+		return LevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func loggerLevelConverter(level slog.Level) logger.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return logger.LevelDebug
+	case level < slog.LevelWarn:
+		return logger.LevelInfo
+	case level < slog.LevelError:
+		return logger.LevelWarn
+	default:
+		// Fatal messages are not used to avoid exit of the process
+		return logger.LevelError
+	}
+}
@@ -0,0 +1,151 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+	"github.com/takama/k8sapp/pkg/logger/standard"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+func TestConformance(t *testing.T) {
+	loggertest.Run(t, loggertest.Adapter{
+		New:    New,
+		Fields: true,
+		JSON:   true,
+	})
+}
+
+func TestFatalLevel(t *testing.T) {
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{Err: err})
+	log.Fatal("message")
+	if got := err.String(); !strings.Contains(got, "level=FATAL") {
+		t.Error("Expected fatal level in message, got", got)
+	}
+}
+
+// recordHandler collects records of slog
+type recordHandler struct {
+	slog.Handler
+	records []slog.Record
+}
+
+func (h *recordHandler) Handle(_ context.Context, record slog.Record) error {
+	h.records = append(h.records, record)
+	return nil
+}
+
+func TestFromHandler(t *testing.T) {
+	handler := &recordHandler{Handler: slog.NewTextHandler(new(bytes.Buffer), nil)}
+	log := FromHandler(handler, &logger.Config{Level: logger.LevelWarn})
+	log.Info("hidden message")
+	log.Warnf("%s message", "warn")
+	log.Error("error message")
+	if len(handler.records) != 2 {
+		t.Fatal("Expected 2 records, got", len(handler.records))
+	}
+	if r := handler.records[0]; r.Level != slog.LevelWarn || r.Message != "warn message" {
+		t.Error("invalid record:", r.Level, r.Message)
+	}
+	if r := handler.records[1]; r.Level != slog.LevelError || r.Message != "error message" {
+		t.Error("invalid record:", r.Level, r.Message)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{handler.records[1].PC}).Next()
+	if !strings.HasSuffix(frame.File, "slog_test.go") {
+		t.Error("Expected source of the record in the test, got", frame.File)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{Out: out, Fields: logger.Fields{"service": "test"}})
+	logger.WithFields(log, logger.Fields{"request_id": "abc"}).Info("request message")
+	log.Info("service message")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Expected 2 messages, got", out.String())
+	}
+	if !strings.Contains(lines[0], "service=test request_id=abc") {
+		t.Error("Expected fields of the request, got", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Error("Expected message without fields of the request, got", lines[1])
+	}
+}
+
+func TestHandler(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := standard.New(&logger.Config{Level: logger.LevelInfo, Out: out, Err: err})
+	l := slog.New(NewHandler(log)).With("component", "test").WithGroup("request")
+	l.Debug("hidden message")
+	l.Info("info message", "id", 17, slog.Group("user", "name", "John Doe"))
+	l.Error("error message", "empty", "")
+	prefix := "[" + config.SERVICENAME + ":"
+	want := prefix + "info] info message component=test request.id=17 request.user.name=\"John Doe\"\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	want = prefix + "error] error message component=test request.empty=\"\"\n"
+	if got := err.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	log.(logger.Leveler).SetLevel(logger.LevelDebug)
+	if !l.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("Expected enabled debug level after change of logger level")
+	}
+}
+
+func TestHandlerFields(t *testing.T) {
+	log := loggertest.New()
+	l := slog.New(NewHandler(log)).With("component", "test").WithGroup("request")
+	l.Warn("warn message", "id", 17, slog.Group("user", "name", "John Doe"))
+	entries := log.Entries()
+	if len(entries) != 1 || entries[0].Message != "warn message" {
+		t.Fatal("Expected message without attributes, got", entries)
+	}
+	want := logger.Fields{"component": "test", "request.id": int64(17), "request.user.name": "John Doe"}
+	if len(entries[0].Fields) != len(want) {
+		t.Errorf("invalid fields:\ngot:  %v\nwant: %v", entries[0].Fields, want)
+	}
+	for key, value := range want {
+		if entries[0].Fields[key] != value {
+			t.Errorf("invalid %s field:\ngot:  %v\nwant: %v", key, entries[0].Fields[key], value)
+		}
+	}
+}
+
+func TestCaller(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:        out,
+		Err:        err,
+		JSON:       true,
+		Caller:     true,
+		Stacktrace: true,
+	})
+	log.Info("message")
+	if got := out.String(); !strings.Contains(got, `"function":"github.com/takama/k8sapp/pkg/logger/slog.TestCaller"`) ||
+		!strings.Contains(got, "slog_test.go") || strings.Contains(got, `"stack"`) {
+		t.Error("Expected caller of the message, got", got)
+	}
+	log.Error("message")
+	if got := err.String(); !strings.Contains(got, `"stack":"github.com/takama/k8sapp/pkg/logger/slog.TestCaller\n`) {
+		t.Error("Expected stack trace of the message, got", got)
+	}
+}
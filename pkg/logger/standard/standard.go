@@ -5,167 +5,217 @@
 package standard
 
 import (
-	"log"
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/logger"
 )
 
-// UTC contains default UTC suffix
-const UTC = "+0000 UTC "
+const (
+	// UTC contains time zone suffix of UTC time.
+	//
+	// Deprecated: the suffix is a part of TimeFormat, it is not used by the logger
+	UTC = "+0000 UTC "
+	// TimeFormat defines format of date and time in messages
+	TimeFormat = "2006/01/02 15:04:05.000000 -0700 MST "
+	// maxBufferSize limits size of buffers which are returned into the pool
+	maxBufferSize = 64 << 10
+)
+
+// Pool of buffers to format messages without allocations
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
 
 // New returns logger that is compatible with the Logger interface
 func New(cfg *logger.Config) logger.Logger {
-	var flags int
-	prefix := "[" + config.SERVICENAME + ":" + cfg.Level.String() + "] "
 	if cfg.Out == nil {
 		cfg.Out = os.Stdout
 	}
 	if cfg.Err == nil {
 		cfg.Err = os.Stderr
 	}
-	if cfg.Time {
-		flags = log.Ldate | log.Ltime | log.Lmicroseconds
-		if cfg.UTC {
-			flags = flags | log.LUTC
-		}
-	}
 	return &stdLogger{
-		Level:  cfg.Level,
-		Time:   cfg.Time,
-		UTC:    cfg.UTC,
-		stdlog: log.New(cfg.Out, prefix, flags),
-		errlog: log.New(cfg.Err, prefix, flags),
+		AtomicLevel: logger.NewAtomicLevel(cfg.Level),
+		Time:        cfg.Time,
+		UTC:         cfg.UTC,
+		caller:      cfg.Caller,
+		callerSkip:  cfg.CallerSkip,
+		stacktrace:  cfg.Stacktrace,
+		levels:      cfg.Levels,
+		mutex:       new(sync.Mutex),
+		out:         cfg.Out,
+		err:         cfg.Err,
 	}
 }
 
-// stdLogger implements the Logger interface
+// stdLogger implements the Logger and Leveler interfaces
 // except of using logger.Fields
 type stdLogger struct {
-	logger.Level
-	Time   bool
-	UTC    bool
-	stdlog *log.Logger
-	errlog *log.Logger
+	*logger.AtomicLevel
+	Time bool
+	UTC  bool
+
+	caller     bool
+	callerSkip int
+	stacktrace bool
+
+	// name of the component and levels of components
+	name   string
+	levels logger.Levels
+
+	// mutex protects outputs, every message is written by one call,
+	// it is shared with component loggers
+	mutex *sync.Mutex
+	out   io.Writer
+	err   io.Writer
+}
+
+// Named returns logger of the component, its name is added into messages
+func (l *stdLogger) Named(name string) logger.Logger {
+	clone := *l
+	clone.name = logger.JoinName(l.name, name)
+	clone.AtomicLevel = l.levels.Level(clone.name, l.AtomicLevel)
+	return &clone
 }
 
 // Debug logs a debug message
 func (l *stdLogger) Debug(v ...interface{}) {
-	if l.Level == logger.LevelDebug {
-		l.setStdPrefix(logger.LevelDebug)
-		l.printStd(v...)
+	if l.Enabled(logger.LevelDebug) {
+		l.print(logger.LevelDebug, v...)
 	}
 }
 
 // Debug logs a debug message with format
 func (l *stdLogger) Debugf(format string, v ...interface{}) {
-	if l.Level == logger.LevelDebug {
-		l.setStdPrefix(logger.LevelDebug)
-		l.printfStd(format, v...)
+	if l.Enabled(logger.LevelDebug) {
+		l.printf(logger.LevelDebug, format, v...)
 	}
 }
 
 // Info logs a info message
 func (l *stdLogger) Info(v ...interface{}) {
-	if l.Level <= logger.LevelInfo {
-		l.setStdPrefix(logger.LevelInfo)
-		l.printStd(v...)
+	if l.Enabled(logger.LevelInfo) {
+		l.print(logger.LevelInfo, v...)
 	}
 }
 
 // Info logs a info message with format
 func (l *stdLogger) Infof(format string, v ...interface{}) {
-	if l.Level <= logger.LevelInfo {
-		l.setStdPrefix(logger.LevelInfo)
-		l.printfStd(format, v...)
+	if l.Enabled(logger.LevelInfo) {
+		l.printf(logger.LevelInfo, format, v...)
 	}
 }
 
 // Warn logs a warning message.
 func (l *stdLogger) Warn(v ...interface{}) {
-	if l.Level <= logger.LevelWarn {
-		l.setStdPrefix(logger.LevelWarn)
-		l.printStd(v...)
+	if l.Enabled(logger.LevelWarn) {
+		l.print(logger.LevelWarn, v...)
 	}
 }
 
 // Warn logs a warning message with format.
 func (l *stdLogger) Warnf(format string, v ...interface{}) {
-	if l.Level <= logger.LevelWarn {
-		l.setStdPrefix(logger.LevelWarn)
-		l.printfStd(format, v...)
+	if l.Enabled(logger.LevelWarn) {
+		l.printf(logger.LevelWarn, format, v...)
 	}
 }
 
 // Error logs an error message
 func (l *stdLogger) Error(v ...interface{}) {
-	if l.Level <= logger.LevelError {
-		l.setErrPrefix(logger.LevelError)
-		l.printErr(v...)
+	if l.Enabled(logger.LevelError) {
+		l.print(logger.LevelError, v...)
 	}
 }
 
 // Error logs an error message with format
 func (l *stdLogger) Errorf(format string, v ...interface{}) {
-	if l.Level <= logger.LevelError {
-		l.setErrPrefix(logger.LevelError)
-		l.printfErr(format, v...)
+	if l.Enabled(logger.LevelError) {
+		l.printf(logger.LevelError, format, v...)
 	}
 }
 
 // Fatal logs an error message followed by a call to os.Exit(1)
 func (l *stdLogger) Fatal(v ...interface{}) {
-	if l.Level <= logger.LevelFatal {
-		l.setErrPrefix(logger.LevelFatal)
-		l.printErr(v...)
+	if l.Enabled(logger.LevelFatal) {
+		l.print(logger.LevelFatal, v...)
 	}
+	logger.Exit(1)
 }
 
 // Fatalf logs an error message with format followed by a call to ox.Exit(1)
 func (l *stdLogger) Fatalf(format string, v ...interface{}) {
-	if l.Level <= logger.LevelFatal {
-		l.setErrPrefix(logger.LevelFatal)
-		l.printfErr(format, v...)
+	if l.Enabled(logger.LevelFatal) {
+		l.printf(logger.LevelFatal, format, v...)
 	}
+	logger.Exit(1)
 }
 
-func (l *stdLogger) printStd(v ...interface{}) {
-	if l.Time && l.UTC {
-		l.stdlog.Print(append([]interface{}{UTC}, v...)...)
-	} else {
-		l.stdlog.Print(v...)
-	}
+func (l *stdLogger) print(level logger.Level, v ...interface{}) {
+	buf := l.header(level)
+	fmt.Fprint(buf, v...)
+	l.write(level, buf)
 }
 
-func (l *stdLogger) printfStd(format string, v ...interface{}) {
-	if l.Time && l.UTC {
-		l.stdlog.Printf("%s"+format, append([]interface{}{UTC}, v...)...)
-	} else {
-		l.stdlog.Printf(format, v...)
-	}
+func (l *stdLogger) printf(level logger.Level, format string, v ...interface{}) {
+	buf := l.header(level)
+	fmt.Fprintf(buf, format, v...)
+	l.write(level, buf)
 }
 
-func (l *stdLogger) printErr(v ...interface{}) {
-	if l.Time && l.UTC {
-		l.errlog.Print(append([]interface{}{UTC}, v...)...)
-	} else {
-		l.errlog.Print(v...)
+// header returns a buffer from the pool with prefix and time of the message
+func (l *stdLogger) header(level logger.Level) *bytes.Buffer {
+	buf := buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.WriteString("[" + config.SERVICENAME + ":")
+	buf.WriteString(level.String())
+	buf.WriteString("] ")
+	if l.Time {
+		now := time.Now()
+		if l.UTC {
+			now = now.UTC()
+		}
+		var scratch [64]byte
+		buf.Write(now.AppendFormat(scratch[:0], TimeFormat))
+	}
+	if l.name != "" {
+		buf.WriteString(l.name)
+		buf.WriteString(": ")
+	}
+	if l.caller {
+		if caller, ok := logger.FindCaller(l.callerSkip); ok {
+			buf.WriteString(caller.String())
+			buf.WriteByte(' ')
+			buf.WriteString(caller.FuncName())
+			buf.WriteString(": ")
+		}
 	}
+	return buf
 }
 
-func (l *stdLogger) printfErr(format string, v ...interface{}) {
-	if l.Time && l.UTC {
-		l.errlog.Printf("%s"+format, append([]interface{}{UTC}, v...)...)
-	} else {
-		l.errlog.Printf(format, v...)
+// write outputs the whole message at once and returns the buffer into the pool
+func (l *stdLogger) write(level logger.Level, buf *bytes.Buffer) {
+	if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	if l.stacktrace && level >= logger.LevelError {
+		buf.WriteString(logger.Stack(l.callerSkip))
+	}
+	out := l.out
+	if level >= logger.LevelError {
+		out = l.err
+	}
+	l.mutex.Lock()
+	out.Write(buf.Bytes())
+	l.mutex.Unlock()
+	if buf.Cap() <= maxBufferSize {
+		buffers.Put(buf)
 	}
-}
-
-func (l *stdLogger) setStdPrefix(level logger.Level) {
-	l.stdlog.SetPrefix("[" + config.SERVICENAME + ":" + level.String() + "] ")
-}
-
-func (l *stdLogger) setErrPrefix(level logger.Level) {
-	l.errlog.SetPrefix("[" + config.SERVICENAME + ":" + level.String() + "] ")
 }
@@ -2,13 +2,24 @@ package standard
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 )
 
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
 func TestNewLog(t *testing.T) {
 	config := &logger.Config{}
 	New(config)
@@ -235,3 +246,170 @@ func TestLogWithTime(t *testing.T) {
 		testOutputFormatedWithTime(t, level, level.String()+" message")
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Level: logger.LevelInfo,
+		Out:   out,
+	})
+	leveler, ok := log.(logger.Leveler)
+	if !ok {
+		t.Fatal("Expected logger which implements Leveler interface")
+	}
+	log.Debug("message")
+	checkNonEmptyMessage(t, out, logger.LevelDebug, leveler.Level())
+	leveler.SetLevel(logger.LevelDebug)
+	log.Debug("message")
+	checkEmptyMessage(t, out, logger.LevelDebug, leveler.Level())
+}
+
+func TestFatalExit(t *testing.T) {
+	code := 0
+	previous := logger.SetExitFunc(func(c int) { code = c })
+	defer logger.SetExitFunc(previous)
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Level: logger.LevelFatal + 1,
+		Err:   err,
+	})
+	log.Fatal("message")
+	if code != 1 {
+		t.Error("Expected exit code 1 after Fatal message, got", code)
+	}
+	code = 0
+	log.Fatalf("%s", "message")
+	if code != 1 {
+		t.Error("Expected exit code 1 after Fatalf message, got", code)
+	}
+	if err.String() != "" {
+		t.Error("Expected filtered fatal messages, got", err.String())
+	}
+}
+
+func TestConformance(t *testing.T) {
+	loggertest.Run(t, loggertest.Adapter{New: New})
+}
+
+func TestTimeZone(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:  out,
+		Time: true,
+	})
+	log.Info("message")
+	zone, _ := time.Now().Zone()
+	if got := out.String(); !strings.Contains(got, " "+zone+" message") {
+		t.Errorf("Expected local time zone %s, got %s", zone, got)
+	}
+	out.Reset()
+	log = New(&logger.Config{
+		Out:  out,
+		Time: true,
+		UTC:  true,
+	})
+	log.Info("message")
+	prefix := "[" + config.SERVICENAME + ":" + logger.LevelInfo.String() + "] "
+	got := out.String()
+	if !strings.HasPrefix(got, prefix) || !strings.HasSuffix(got, " "+UTC+"message\n") {
+		t.Errorf("Expected UTC time, got %s", got)
+	}
+	if _, err := time.Parse(TimeFormat+"message\n", strings.TrimPrefix(got, prefix)); err != nil {
+		t.Error("Expected time in the message, got", err)
+	}
+}
+
+func TestConcurrentPrefixes(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out: out,
+		Err: out,
+	})
+	var wg sync.WaitGroup
+	for _, level := range []logger.Level{
+		logger.LevelDebug,
+		logger.LevelInfo,
+		logger.LevelWarn,
+		logger.LevelError,
+	} {
+		wg.Add(1)
+		go func(level logger.Level) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				loggertest.Logf(log, level, "%s message", level)
+			}
+		}(level)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 4*500 {
+		t.Errorf("Expected %d lines, got %d", 4*500, len(lines))
+	}
+	for _, line := range lines {
+		// Every line looks like "[K8SAPP:info] info message"
+		parts := strings.SplitN(strings.TrimPrefix(line, "["+config.SERVICENAME+":"), "] ", 2)
+		if len(parts) != 2 || parts[1] != parts[0]+" message" {
+			t.Fatalf("Got message with wrong level prefix: %s", line)
+		}
+	}
+}
+
+func benchmarkLogger(b *testing.B, cfg *logger.Config, log func(logger.Logger)) {
+	cfg.Out = ioutil.Discard
+	cfg.Err = ioutil.Discard
+	l := New(cfg)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log(l)
+		}
+	})
+}
+
+func BenchmarkInfo(b *testing.B) {
+	benchmarkLogger(b, &logger.Config{}, func(l logger.Logger) {
+		l.Info("message")
+	})
+}
+
+func BenchmarkInfof(b *testing.B) {
+	benchmarkLogger(b, &logger.Config{}, func(l logger.Logger) {
+		l.Infof("message %d", 17)
+	})
+}
+
+func BenchmarkInfoWithTime(b *testing.B) {
+	benchmarkLogger(b, &logger.Config{Time: true, UTC: true}, func(l logger.Logger) {
+		l.Info("message")
+	})
+}
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	benchmarkLogger(b, &logger.Config{Level: logger.LevelInfo}, func(l logger.Logger) {
+		l.Debug("message")
+	})
+}
+
+func TestCaller(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:        out,
+		Err:        err,
+		Caller:     true,
+		Stacktrace: true,
+	})
+	log.Info("message")
+	if got := out.String(); !strings.Contains(got, "] standard/standard_test.go:") ||
+		!strings.HasSuffix(got, " standard.TestCaller: message\n") {
+		t.Error("Expected caller of the message, got", got)
+	}
+	log.Errorf("%s", "message")
+	lines := strings.Split(err.String(), "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[0], " standard.TestCaller: message") ||
+		lines[1] != "github.com/takama/k8sapp/pkg/logger/standard.TestCaller" ||
+		!strings.Contains(lines[2], "standard_test.go:") {
+		t.Error("Expected stack trace of the message, got", err.String())
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package termination
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+const (
+	// DefaultPath is the termination message file of Kubernetes containers
+	DefaultPath = "/dev/termination-log"
+	// MaxSize limits termination messages like Kubernetes does
+	MaxSize = 4096
+	// DefaultErrors is a number of recent error messages in the report
+	DefaultErrors = 5
+)
+
+// Config contains settings of termination messages
+type Config struct {
+	// Path of the termination message file
+	Path string
+	// Number of recent error messages in the report
+	Errors int
+	// Version of the service
+	Version string
+}
+
+// Reporter writes reason of fatal exits into the termination message file
+type Reporter struct {
+	log    logger.Logger
+	config Config
+	*recent
+}
+
+// recent error messages are shared between the reporter and its components
+type recent struct {
+	mutex  sync.Mutex
+	errors []string
+}
+
+// New returns logger which writes termination message on fatal messages
+func New(log logger.Logger, config Config) *Reporter {
+	if config.Errors <= 0 {
+		config.Errors = DefaultErrors
+	}
+	return &Reporter{log: log, config: config, recent: new(recent)}
+}
+
+// Named returns logger of the component which shares recent error messages
+func (r *Reporter) Named(name string) logger.Logger {
+	return &Reporter{log: logger.Named(r.log, name), config: r.config, recent: r.recent}
+}
+
+// WithFields returns logger which adds the fields into every message
+// and shares recent error messages
+func (r *Reporter) WithFields(fields logger.Fields) logger.Logger {
+	return &Reporter{log: logger.WithFields(r.log, fields), config: r.config, recent: r.recent}
+}
+
+// Level returns the current log level
+func (r *Reporter) Level() logger.Level {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		return leveler.Level()
+	}
+	return logger.LevelDebug
+}
+
+// SetLevel changes the log level
+func (r *Reporter) SetLevel(level logger.Level) {
+	if leveler, ok := r.log.(logger.Leveler); ok {
+		leveler.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message
+func (r *Reporter) Debug(v ...interface{}) {
+	r.log.Debug(v...)
+}
+
+// Debug logs a debug message with format
+func (r *Reporter) Debugf(format string, v ...interface{}) {
+	r.log.Debugf(format, v...)
+}
+
+// Info logs a info message
+func (r *Reporter) Info(v ...interface{}) {
+	r.log.Info(v...)
+}
+
+// Info logs a info message with format
+func (r *Reporter) Infof(format string, v ...interface{}) {
+	r.log.Infof(format, v...)
+}
+
+// Warn logs a warning message.
+func (r *Reporter) Warn(v ...interface{}) {
+	r.log.Warn(v...)
+}
+
+// Warn logs a warning message with format.
+func (r *Reporter) Warnf(format string, v ...interface{}) {
+	r.log.Warnf(format, v...)
+}
+
+// Error logs an error message
+func (r *Reporter) Error(v ...interface{}) {
+	if logger.LevelError >= r.Level() {
+		r.keep(fmt.Sprint(v...))
+	}
+	r.log.Error(v...)
+}
+
+// Error logs an error message with format
+func (r *Reporter) Errorf(format string, v ...interface{}) {
+	if logger.LevelError >= r.Level() {
+		r.keep(fmt.Sprintf(format, v...))
+	}
+	r.log.Errorf(format, v...)
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (r *Reporter) Fatal(v ...interface{}) {
+	r.Report("fatal: " + fmt.Sprint(v...))
+	r.log.Fatal(v...)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (r *Reporter) Fatalf(format string, v ...interface{}) {
+	r.Report("fatal: " + fmt.Sprintf(format, v...))
+	r.log.Fatalf(format, v...)
+}
+
+// Report writes the reason with recent error messages and version
+// into the termination message file
+func (r *Reporter) Report(reason string) error {
+	return r.report(reason, "")
+}
+
+// Unwrap returns the wrapped logger
+func (r *Reporter) Unwrap() logger.Logger {
+	return r.log
+}
+
+// report writes the message, details are appended after recent errors
+func (r *Reporter) report(reason, details string) error {
+	r.mutex.Lock()
+	errors := make([]string, len(r.errors))
+	copy(errors, r.errors)
+	r.mutex.Unlock()
+	msg := Message(reason, r.config.Version, errors)
+	if details != "" && len(msg) < MaxSize {
+		msg = truncate(msg + "\n" + details)
+	}
+	return Write(r.config.Path, msg)
+}
+
+func (r *Reporter) keep(msg string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.errors) == r.config.Errors {
+		r.errors = append(r.errors[:0], r.errors[1:]...)
+	}
+	r.errors = append(r.errors, time.Now().UTC().Format(time.RFC3339)+" "+msg)
+}
+
+// Recover writes the termination message about unrecovered panic and panics again,
+// the reporter is looked up in wrappers of the logger. It must be deferred
+// in the main function:
+//
+//	defer termination.Recover(log)
+//
+// Panics of other goroutines can not be reported
+func Recover(log logger.Logger) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if reporter := find(log); reporter != nil {
+		reporter.report(fmt.Sprintf("panic: %v", p), string(debug.Stack()))
+	}
+	panic(p)
+}
+
+// Report writes the termination message by the reporter which is looked up
+// in wrappers of the logger, it is used for exits without fatal messages
+func Report(log logger.Logger, reason string) error {
+	if reporter := find(log); reporter != nil {
+		return reporter.Report(reason)
+	}
+	return nil
+}
+
+func find(log logger.Logger) *Reporter {
+	for log != nil {
+		if reporter, ok := log.(*Reporter); ok {
+			return reporter
+		}
+		wrapper, ok := log.(logger.Wrapper)
+		if !ok {
+			return nil
+		}
+		log = wrapper.Unwrap()
+	}
+	return nil
+}
+
+// Message returns termination message limited by MaxSize
+func Message(reason, version string, errors []string) string {
+	var msg strings.Builder
+	msg.WriteString(strings.TrimSpace(reason) + "\n")
+	if version != "" {
+		msg.WriteString("version: " + version + "\n")
+	}
+	if len(errors) > 0 {
+		msg.WriteString("recent errors:\n")
+		for _, err := range errors {
+			msg.WriteString(err + "\n")
+		}
+	}
+	return truncate(msg.String())
+}
+
+// truncate limits the text by MaxSize without splitting of UTF-8 characters
+func truncate(text string) string {
+	if len(text) <= MaxSize {
+		return text
+	}
+	size := MaxSize
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size]
+}
+
+// Write writes the message into the termination message file, nothing is written
+// if the path is empty or its directory does not exist. The file of DefaultPath
+// is mounted by Kubernetes, it is not created outside of containers
+func Write(path, msg string) error {
+	if path == "" {
+		return nil
+	}
+	flag := os.O_WRONLY | os.O_TRUNC
+	if path != DefaultPath {
+		if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
+			return nil
+		}
+		flag |= os.O_CREATE
+	}
+	file, err := os.OpenFile(path, flag, 0644)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = file.WriteString(msg); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
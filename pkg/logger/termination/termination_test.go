@@ -0,0 +1,124 @@
+package termination
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
+func readMessage(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFatal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	log := loggertest.New()
+	reporter := New(log, Config{Path: path, Errors: 2, Version: "1.2.3"})
+	reporter.Info("info message")
+	reporter.Error("first error")
+	reporter.Errorf("%s error", "second")
+	reporter.Error("third error")
+	reporter.SetLevel(logger.LevelFatal)
+	reporter.Error("filtered error")
+	reporter.Fatalf("can not %s", "start")
+	if !log.Contains(logger.LevelFatal, "can not start") || log.Len() != 5 {
+		t.Error("Expected all messages in the logger, got", log.Entries())
+	}
+	msg := readMessage(t, path)
+	if !strings.HasPrefix(msg, "fatal: can not start\nversion: 1.2.3\nrecent errors:\n") {
+		t.Error("Expected reason and version in the termination message, got", msg)
+	}
+	if strings.Contains(msg, "first error") || !strings.Contains(msg, "second error") ||
+		!strings.Contains(msg, "third error") || strings.Contains(msg, "info message") ||
+		strings.Contains(msg, "filtered error") {
+		t.Error("Expected 2 last errors in the termination message, got", msg)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	log := logger.WithFields(New(loggertest.New(), Config{Path: path}), logger.Fields{"key": "value"})
+	func() {
+		defer func() {
+			if p := recover(); p != "unexpected" {
+				t.Error("Expected repeated panic, got", p)
+			}
+		}()
+		defer Recover(log)
+		panic("unexpected")
+	}()
+	msg := readMessage(t, path)
+	if !strings.HasPrefix(msg, "panic: unexpected\n") || !strings.Contains(msg, "TestRecover") {
+		t.Error("Expected panic with stack in the termination message, got", msg)
+	}
+}
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	log := logger.Named(New(loggertest.New(), Config{Path: path}), "main")
+	if err := Report(log, "can not bind"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readMessage(t, path); msg != "can not bind\n" {
+		t.Errorf("invalid termination message:\ngot:  %q\nwant: %q", msg, "can not bind\n")
+	}
+	if err := Report(loggertest.New(), "no reporter"); err != nil {
+		t.Error("Expected ignored report without reporter, got", err)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	msg := Message(strings.Repeat("x", 2*MaxSize), "1.0.0", []string{"error"})
+	if len(msg) != MaxSize {
+		t.Error("Expected message limited by", MaxSize, "got", len(msg))
+	}
+	// Characters are not split by the limit
+	msg = Message("x"+strings.Repeat("я", MaxSize), "", nil)
+	if len(msg) != MaxSize-1 || !utf8.ValidString(msg) {
+		t.Error("Expected valid message limited by", MaxSize-1, "got", len(msg))
+	}
+	if err := Write("", "message"); err != nil {
+		t.Error("Expected disabled writing, got", err)
+	}
+	path := filepath.Join(t.TempDir(), "missing", "file")
+	if err := Write(path, "message"); err != nil {
+		t.Error("Expected skipped writing into missing directory, got", err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Error("Expected missing directory, got", err)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	log := loggertest.New()
+	reporter := New(log, Config{Path: path})
+	request := logger.WithFields(reporter, logger.Fields{"request_id": "abc"})
+	if _, ok := request.(*Reporter); !ok {
+		t.Errorf("invalid logger with fields:\ngot:  %T\nwant: %T", request, reporter)
+	}
+	request.Error("request error")
+	reporter.Fatal("can not start")
+	if entries := log.Entries(); entries[0].Fields["request_id"] != "abc" || entries[0].Message != "request error" {
+		t.Error("Expected message with fields of the request, got", entries[0])
+	}
+	if msg := readMessage(t, path); !strings.Contains(msg, "request error") {
+		t.Error("Expected shared recent errors in the termination message, got", msg)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"log"
+	"sync"
+)
+
+// Writer logs every written line as a message with the level,
+// it redirects output of packages which use io.Writer or *log.Logger
+type Writer struct {
+	log   Logger
+	level Level
+
+	// mutex protects incomplete line which is kept until its end is written
+	mutex sync.Mutex
+	line  []byte
+}
+
+// NewWriter returns io.Writer which logs lines with the level,
+// lines with fatal level are logged as errors to avoid exit
+func NewWriter(log Logger, level Level) *Writer {
+	return &Writer{log: log, level: level}
+}
+
+// NewStdLog returns *log.Logger which sends messages into the logger,
+// e.g. for http.Server.ErrorLog
+func NewStdLog(l Logger, level Level) *log.Logger {
+	return log.New(NewWriter(l, level), "", 0)
+}
+
+// RedirectStdLog sends messages of the standard log package into the logger
+func RedirectStdLog(l Logger, level Level) {
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(NewWriter(l, level))
+}
+
+// Write logs complete lines of the data, the rest is kept until the next write
+func (w *Writer) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	n := len(data)
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.line = append(w.line, data...)
+			break
+		}
+		if len(w.line) > 0 {
+			w.line = append(w.line, data[:i]...)
+			w.output(w.line)
+			w.line = w.line[:0]
+		} else {
+			w.output(data[:i])
+		}
+		data = data[i+1:]
+	}
+	return n, nil
+}
+
+// Flush logs the incomplete line
+func (w *Writer) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.line) > 0 {
+		w.output(w.line)
+		w.line = w.line[:0]
+	}
+}
+
+func (w *Writer) output(line []byte) {
+	msg := string(bytes.TrimRight(line, "\r"))
+	if msg == "" {
+		return
+	}
+	switch w.level {
+	case LevelDebug:
+		w.log.Debug(msg)
+	case LevelInfo:
+		w.log.Info(msg)
+	case LevelWarn:
+		w.log.Warn(msg)
+	default:
+		w.log.Error(msg)
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+// levelMessages collects levels of messages
+type levelMessages struct {
+	messages
+	levels []Level
+}
+
+func (m *levelMessages) Debug(v ...interface{}) { m.log(LevelDebug, v) }
+func (m *levelMessages) Info(v ...interface{})  { m.log(LevelInfo, v) }
+func (m *levelMessages) Warn(v ...interface{})  { m.log(LevelWarn, v) }
+func (m *levelMessages) Error(v ...interface{}) { m.log(LevelError, v) }
+func (m *levelMessages) Fatal(v ...interface{}) { m.log(LevelFatal, v) }
+
+func (m *levelMessages) log(level Level, v []interface{}) {
+	m.levels = append(m.levels, level)
+	m.add(fmt.Sprint(v...))
+}
+
+func TestWriter(t *testing.T) {
+	log := new(levelMessages)
+	w := NewWriter(log, LevelWarn)
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\r\n\nthird")
+	if len(log.list) != 2 {
+		t.Fatal("Expected 2 complete lines, got", log.list)
+	}
+	w.Flush()
+	want := []string{"first line", "second line", "third"}
+	if len(log.list) != len(want) {
+		t.Fatal("Expected", len(want), "messages, got", log.list)
+	}
+	for i, msg := range log.list {
+		if msg != want[i] || log.levels[i] != LevelWarn {
+			t.Errorf("invalid message:\ngot:  %v %v\nwant: %v %v", log.levels[i], msg, LevelWarn, want[i])
+		}
+	}
+}
+
+func TestStdLog(t *testing.T) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal} {
+		log := new(levelMessages)
+		NewStdLog(log, level).Printf("http: TLS handshake error from %s", "127.0.0.1")
+		want := level
+		if level == LevelFatal {
+			// Lines must not terminate the application
+			want = LevelError
+		}
+		if len(log.list) != 1 || log.levels[0] != want {
+			t.Fatalf("invalid %s message: %v %v", level, log.levels, log.list)
+		}
+		if log.list[0] != "http: TLS handshake error from 127.0.0.1" {
+			t.Error("Expected message without time and prefix, got", log.list[0])
+		}
+	}
+}
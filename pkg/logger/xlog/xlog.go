@@ -2,28 +2,194 @@
 // Use of this source code is governed by a MIT-style
 // license that can be found in the LICENSE file.
 
-package logger
+package xlog
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/rs/xlog"
 	"github.com/takama/k8sapp/pkg/logger"
 )
 
-// newXLog creates "github.com/rs/xlog" logger
-func newXLog(config *logger.Config) logger.Logger {
-	var out xlog.Output
-	switch config.Err {
-	// We should find more matches between types of output
-	case nil, os.Stderr:
-		out = xlog.NewConsoleOutput()
-	default:
-		out = xlog.NewConsoleOutput()
-	}
-	return xlog.New(xlog.Config{
-		Level:  xlog.Level(config.Level),
-		Fields: config.Fields,
-		Output: out,
+// New creates "github.com/rs/xlog" logger
+func New(cfg *logger.Config) logger.Logger {
+	if cfg.Out == nil {
+		cfg.Out = os.Stdout
+	}
+	if cfg.Err == nil {
+		cfg.Err = os.Stderr
+	}
+	return &xLogger{
+		// Levels are filtered by xLogger to make them changeable at runtime
+		Logger: xlog.New(xlog.Config{
+			Level:  xlog.LevelDebug,
+			Fields: cfg.Fields,
+			Output: newOutput(cfg),
+		}),
+		AtomicLevel: logger.NewAtomicLevel(cfg.Level),
+		caller:      cfg.Caller,
+		callerSkip:  cfg.CallerSkip,
+		stacktrace:  cfg.Stacktrace,
+		levels:      cfg.Levels,
+	}
+}
+
+// newOutput routes messages of levels debug, info, warn into Out
+// and messages of levels error, fatal into Err
+func newOutput(cfg *logger.Config) xlog.Output {
+	out := newFormatOutput(cfg.Out, cfg.JSON)
+	err := out
+	if cfg.Err != cfg.Out {
+		err = newFormatOutput(cfg.Err, cfg.JSON)
+	}
+	return xlog.OutputFunc(func(fields map[string]interface{}) error {
+		if t, ok := fields[xlog.KeyTime].(time.Time); ok {
+			switch {
+			case !cfg.Time:
+				delete(fields, xlog.KeyTime)
+			case cfg.UTC:
+				fields[xlog.KeyTime] = t.UTC()
+			}
+		}
+		switch fmt.Sprint(fields[xlog.KeyLevel]) {
+		case logger.LevelError.String(), logger.LevelFatal.String():
+			return err.Write(fields)
+		default:
+			return out.Write(fields)
+		}
 	})
 }
+
+func newFormatOutput(w io.Writer, json bool) xlog.Output {
+	if json {
+		return xlog.NewJSONOutput(w)
+	}
+	// Colored console output is used for terminals only
+	return xlog.NewConsoleOutputW(w, xlog.NewLogfmtOutput(w))
+}
+
+// xLogger implements the Logger and Leveler interfaces
+type xLogger struct {
+	xlog.Logger
+	*logger.AtomicLevel
+	caller     bool
+	callerSkip int
+	stacktrace bool
+
+	// name of the component, levels of components and fields of messages
+	name   string
+	levels logger.Levels
+	fields map[string]interface{}
+}
+
+// Named returns logger of the component, its name is added into messages
+func (l *xLogger) Named(name string) logger.Logger {
+	name = logger.JoinName(l.name, name)
+	clone := l.with(logger.Fields{logger.FieldComponent: name})
+	clone.name = name
+	clone.AtomicLevel = l.levels.Level(clone.name, l.AtomicLevel)
+	return clone
+}
+
+// WithFields returns logger which adds the fields into every message
+func (l *xLogger) WithFields(fields logger.Fields) logger.Logger {
+	return l.with(fields)
+}
+
+func (l *xLogger) with(fields logger.Fields) *xLogger {
+	clone := *l
+	clone.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		clone.fields[key] = value
+	}
+	for key, value := range fields {
+		clone.fields[key] = value
+	}
+	return &clone
+}
+
+// Debug logs a debug message
+func (l *xLogger) Debug(v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprint(v...))
+	}
+}
+
+// Debug logs a debug message with format
+func (l *xLogger) Debugf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelDebug) {
+		l.log(logger.LevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+// Info logs a info message
+func (l *xLogger) Info(v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprint(v...))
+	}
+}
+
+// Info logs a info message with format
+func (l *xLogger) Infof(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelInfo) {
+		l.log(logger.LevelInfo, fmt.Sprintf(format, v...))
+	}
+}
+
+// Warn logs a warning message.
+func (l *xLogger) Warn(v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprint(v...))
+	}
+}
+
+// Warn logs a warning message with format.
+func (l *xLogger) Warnf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelWarn) {
+		l.log(logger.LevelWarn, fmt.Sprintf(format, v...))
+	}
+}
+
+// Error logs an error message
+func (l *xLogger) Error(v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprint(v...))
+	}
+}
+
+// Error logs an error message with format
+func (l *xLogger) Errorf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelError) {
+		l.log(logger.LevelError, fmt.Sprintf(format, v...))
+	}
+}
+
+// Fatal logs an error message followed by a call to os.Exit(1)
+func (l *xLogger) Fatal(v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprint(v...))
+	}
+	logger.Exit(1)
+}
+
+// Fatalf logs an error message with format followed by a call to ox.Exit(1)
+func (l *xLogger) Fatalf(format string, v ...interface{}) {
+	if l.Enabled(logger.LevelFatal) {
+		l.log(logger.LevelFatal, fmt.Sprintf(format, v...))
+	}
+	logger.Exit(1)
+}
+
+func (l *xLogger) log(level logger.Level, msg string) {
+	fields := l.fields
+	if l.caller || l.stacktrace {
+		fields = logger.Annotations(level, l.caller, l.stacktrace, l.callerSkip)
+		for key, value := range l.fields {
+			fields[key] = value
+		}
+	}
+	l.Logger.OutputF(xlog.Level(level), 3, msg, fields)
+}
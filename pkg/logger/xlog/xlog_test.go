@@ -1,25 +1,78 @@
-package logger
+package xlog
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 )
 
+func TestMain(m *testing.M) {
+	// Fatal messages must not terminate tests
+	logger.SetExitFunc(func(int) {})
+	os.Exit(m.Run())
+}
+
 func TestNewXLog(t *testing.T) {
-	log1 := newXLog(&logger.Config{
+	config := &logger.Config{
 		Level: logger.LevelDebug,
-	})
-	if log1 == nil {
+	}
+	log := New(config)
+	if log == nil {
 		t.Error("Got uninitialized XLog logger")
 	}
-	log2 := newXLog(&logger.Config{
-		Level: logger.LevelInfo,
-		Out:   os.Stdout,
-		Err:   os.Stdout,
+	if config.Out != os.Stdout {
+		t.Error("Invalid logger output, want os.Stdout")
+	}
+	if config.Err != os.Stderr {
+		t.Error("Invalid logger error output, want os.Stderr")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	loggertest.Run(t, loggertest.Adapter{
+		New:    New,
+		Fields: true,
+		JSON:   true,
 	})
-	if log2 == nil {
-		t.Error("Got uninitialized XLog logger")
+}
+
+func TestUTC(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:  out,
+		Time: true,
+		UTC:  true,
+		JSON: true,
+	})
+	log.Info("message")
+	if got := out.String(); !strings.Contains(got, time.Now().UTC().Format("2006-01-02T15")) ||
+		!strings.Contains(got, "Z\"") {
+		t.Error("Expected UTC time in message, got", got)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := &bytes.Buffer{}
+	log := New(&logger.Config{
+		Out:        out,
+		Err:        err,
+		JSON:       true,
+		Caller:     true,
+		Stacktrace: true,
+	})
+	log.Info("message")
+	if got := out.String(); !strings.Contains(got, `"caller":"xlog/xlog_test.go:`) ||
+		!strings.Contains(got, `"func":"xlog.TestCaller"`) || strings.Contains(got, `"stack"`) {
+		t.Error("Expected caller of the message, got", got)
+	}
+	log.Error("message")
+	if got := err.String(); !strings.Contains(got, `"stack":"github.com/takama/k8sapp/pkg/logger/xlog.TestCaller\n`) {
+		t.Error("Expected stack trace of the message, got", got)
 	}
 }
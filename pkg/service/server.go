@@ -0,0 +1,193 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/handlers"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/system"
+)
+
+// ReadinessPath is a path of the readiness probe
+const ReadinessPath = "/readyz"
+
+// ErrShutdownTimeout is returned if requests are not completed in the shutdown timeout
+var ErrShutdownTimeout = errors.New("timeout of graceful shutdown")
+
+// Server serves the router, the readiness probe succeeds
+// only while the listener is bound and the server is not shutting down
+type Server struct {
+	*http.Server
+	log   logger.Logger
+	ready int32
+
+	mutex    sync.Mutex
+	listener net.Listener
+
+	// closing is closed when the shutdown starts
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer returns HTTP server for the router, errors of the server
+// are logged by the logger, requests get loggers with their fields
+func NewServer(cfg *config.Config, r bit.Router, log logger.Logger) (*Server, error) {
+	handler, ok := r.(http.Handler)
+	if !ok {
+		return nil, fmt.Errorf("router %T does not implement http.Handler", r)
+	}
+	s := &Server{log: log, closing: make(chan struct{})}
+	s.Server = &http.Server{
+		Addr:     fmt.Sprintf("%s:%d", cfg.LocalHost, cfg.LocalPort),
+		Handler:  handlers.RequestLogger(log, s.readiness(handler)),
+		ErrorLog: logger.NewStdLog(log, cfg.LogStdLevel),
+		// Shutdown does not cancel contexts of active requests,
+		// streaming handlers are completed by the shutdown channel
+		BaseContext: func(net.Listener) context.Context {
+			return handlers.WithShutdown(context.Background(), s.closing)
+		},
+	}
+	s.RegisterOnShutdown(func() {
+		s.closeOnce.Do(func() { close(s.closing) })
+	})
+	return s, nil
+}
+
+// Listen binds the listener of the server address, the server becomes ready
+func (s *Server) Listen() error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+	atomic.StoreInt32(&s.ready, 1)
+	s.log.Infof("Service %s listened on %s", config.SERVICENAME, listener.Addr())
+	return nil
+}
+
+// ListenerAddr returns address of the bound listener or the configured address
+func (s *Server) ListenerAddr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.listener == nil {
+		return s.Addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Serve serves connections of the bound listener in background,
+// the channel receives an error if serving fails, it is closed after shutdown
+func (s *Server) Serve() <-chan error {
+	errs := make(chan error, 1)
+	s.mutex.Lock()
+	listener := s.listener
+	s.mutex.Unlock()
+	if listener == nil {
+		errs <- errors.New("listener of the server is not bound")
+		close(errs)
+		return errs
+	}
+	go func() {
+		defer close(errs)
+		if err := s.Server.Serve(listener); err != http.ErrServerClosed {
+			atomic.StoreInt32(&s.ready, 0)
+			errs <- err
+		}
+	}()
+	return errs
+}
+
+// Ready reports whether the server is ready to serve traffic
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// Shutdown stops accepting of new connections and waits for active requests,
+// the readiness probe fails during the shutdown
+func (s *Server) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&s.ready, 0)
+	return s.Server.Shutdown(ctx)
+}
+
+// readiness fails the readiness probe until the server is ready
+func (s *Server) readiness(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ReadinessPath && !s.Ready() {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Run serves the router until the context is cancelled, e.g. the root context
+// of system.Signals, then the server is shut down gracefully.
+// Errors are returned with exit codes of their failure classes
+func Run(ctx context.Context, cfg *config.Config, r bit.Router, log logger.Logger) error {
+	server, err := NewServer(cfg, r, log)
+	if err != nil {
+		return system.NewExitError(system.ExitConfig, err)
+	}
+	// The service becomes ready when the listener is bound
+	if err := server.Listen(); err != nil {
+		return system.NewExitError(system.ExitListen, err)
+	}
+	select {
+	case err := <-server.Serve():
+		return system.NewExitError(system.ExitListen, err)
+	case <-ctx.Done():
+	}
+	err = NewOperator(server, cfg.ShutdownTimeout).Shutdown()
+	if err == ErrShutdownTimeout {
+		return system.NewExitError(system.ExitShutdownTimeout, err)
+	}
+	return system.NewExitError(system.ExitFailure, err)
+}
+
+// Operator shuts down the server gracefully
+type Operator struct {
+	system.Handling
+	server  *Server
+	timeout time.Duration
+}
+
+// NewOperator returns operator which shuts down the server in the timeout,
+// the shutdown is not limited if the timeout is zero
+func NewOperator(server *Server, timeout time.Duration) *Operator {
+	return &Operator{server: server, timeout: timeout}
+}
+
+// Shutdown shuts down the server and waits for buffered log messages
+func (o *Operator) Shutdown() error {
+	ctx := context.Background()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	err := o.server.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		err = ErrShutdownTimeout
+	}
+	if flushErr := logger.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
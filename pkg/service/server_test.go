@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/takama/bit"
+	// Alternative of the Bit router with the same Router interface
+	// "github.com/takama/k8sapp/pkg/router/httprouter"
+	"github.com/takama/k8sapp/pkg/config"
+	"github.com/takama/k8sapp/pkg/handlers"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+	"github.com/takama/k8sapp/pkg/system"
+)
+
+func newTestServer(t *testing.T) (*Server, bit.Router) {
+	cfg := &config.Config{LocalHost: "127.0.0.1"}
+	router, log, err := Setup(cfg)
+	if err != nil {
+		t.Fatalf("Fail, got '%s', want '%v'", err, nil)
+	}
+	server, err := NewServer(cfg, router, log)
+	if err != nil {
+		t.Fatalf("Fail, got '%s', want '%v'", err, nil)
+	}
+	return server, router
+}
+
+func readiness(server *Server) int {
+	trw := httptest.NewRecorder()
+	server.Handler.ServeHTTP(trw, httptest.NewRequest("GET", ReadinessPath, nil))
+	return trw.Code
+}
+
+func TestServer(t *testing.T) {
+	server, _ := newTestServer(t)
+	if code := readiness(server); code != http.StatusServiceUnavailable {
+		t.Error("Expected status code before listening:", http.StatusServiceUnavailable, "got", code)
+	}
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	errs := server.Serve()
+	resp, err := http.Get("http://" + server.ListenerAddr() + ReadinessPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Error("Expected status code after listening:", http.StatusOK, "got", resp.StatusCode)
+	}
+	if resp.Header.Get(handlers.RequestIDHeader) == "" {
+		t.Error("Expected request ID in response header")
+	}
+	if err := NewOperator(server, time.Second).Shutdown(); err != nil {
+		t.Error("Expected graceful shutdown, got", err)
+	}
+	if code := readiness(server); code != http.StatusServiceUnavailable {
+		t.Error("Expected status code after shutdown:", http.StatusServiceUnavailable, "got", code)
+	}
+	if err, ok := <-errs; ok || err != nil {
+		t.Error("Expected closed channel of errors, got", err)
+	}
+}
+
+func TestListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	server, err := NewServer(
+		&config.Config{LocalHost: "127.0.0.1", LocalPort: busy.Addr().(*net.TCPAddr).Port},
+		bit.NewRouter(), loggertest.New(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Listen(); err == nil {
+		t.Error("Expected error of busy address, got", err)
+	}
+	if server.Ready() {
+		t.Error("Expected not ready server")
+	}
+	if err := <-server.Serve(); err == nil {
+		t.Error("Expected error of serving without listener, got", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	server, router := newTestServer(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	router.GET("/slow", func(c bit.Control) {
+		close(started)
+		<-release
+	})
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	server.Serve()
+	go http.Get("http://" + server.ListenerAddr() + "/slow")
+	<-started
+	if err := NewOperator(server, 10*time.Millisecond).Shutdown(); err != ErrShutdownTimeout {
+		t.Error("Expected", ErrShutdownTimeout, "got", err)
+	}
+}
+
+func TestShutdownStream(t *testing.T) {
+	cfg := &config.Config{LocalHost: "127.0.0.1", LogBufferSize: 10, AdminToken: "secret"}
+	router, log, err := Setup(cfg)
+	if err != nil {
+		t.Fatalf("Fail, got '%s', want '%v'", err, nil)
+	}
+	server, err := NewServer(cfg, router, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	server.Serve()
+	req, err := http.NewRequest("GET", "http://"+server.ListenerAddr()+"/debug/logs?follow=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Expected open stream of logs, got", resp.StatusCode)
+	}
+	if err := NewOperator(server, time.Second).Shutdown(); err != nil {
+		t.Error("Expected graceful shutdown with open stream of logs, got", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{LocalHost: "127.0.0.1", ShutdownTimeout: time.Second}
+	router, log, err := Setup(cfg)
+	if err != nil {
+		t.Fatalf("Fail, got '%s', want '%v'", err, nil)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Run(ctx, cfg, router, log); err != nil {
+		t.Error("Expected graceful shutdown, got", err)
+	}
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	cfg.LocalPort = busy.Addr().(*net.TCPAddr).Port
+	if code := system.ExitCode(Run(context.Background(), cfg, router, log)); code != system.ExitListen {
+		t.Error("Expected exit code", system.ExitListen, "got", code)
+	}
+}
@@ -5,7 +5,14 @@
 package service
 
 import (
+	"fmt"
+	"io"
+	stdslog "log/slog"
 	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/takama/bit"
 	// Alternative of the Bit router with the same Router interface
@@ -13,25 +20,120 @@ import (
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/handlers"
 	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/async"
+	"github.com/takama/k8sapp/pkg/logger/redact"
+	"github.com/takama/k8sapp/pkg/logger/ring"
+	"github.com/takama/k8sapp/pkg/logger/sampling"
+	"github.com/takama/k8sapp/pkg/logger/sentry"
+	"github.com/takama/k8sapp/pkg/logger/sink"
+	"github.com/takama/k8sapp/pkg/logger/slog"
 	stdlog "github.com/takama/k8sapp/pkg/logger/standard"
+	"github.com/takama/k8sapp/pkg/logger/termination"
 	"github.com/takama/k8sapp/pkg/version"
 )
 
 // Setup configures the service
 func Setup(cfg *config.Config) (r bit.Router, log logger.Logger, err error) {
 	// Setup logger
-	log = stdlog.New(&logger.Config{
-		Level: cfg.LogLevel,
-		Time:  true,
-		UTC:   true,
+	out, errOut := outputs(cfg)
+	var resources setupOutputs
+	for _, w := range []io.Writer{out, errOut} {
+		if writer, ok := w.(*async.Writer); ok {
+			resources.flushers = append(resources.flushers, writer.Flush)
+			resources.closers = append(resources.closers, func() { writer.Close() })
+		}
+	}
+	// Outputs replace outputs of the previous setup, they are closed on errors
+	defer func() {
+		if err != nil {
+			resources.release()
+			return
+		}
+		replaceOutputs(resources)
+	}()
+	log, err = output(&logger.Config{
+		Level:       cfg.LogLevel,
+		Levels:      cfg.LogLevels,
+		Time:        true,
+		UTC:         true,
+		Caller:      cfg.LogCaller,
+		Stacktrace:  cfg.LogStacktrace,
+		Out:         out,
+		Err:         errOut,
+		Sink:        cfg.LogSink,
+		SinkNetwork: cfg.LogSinkNetwork,
+		SinkAddress: cfg.LogSinkAddress,
+		Name:        strings.ToLower(config.SERVICENAME),
 	})
+	if err != nil {
+		return
+	}
+	// The connection of the sink is closed after messages of the fatal exit
+	sinkLog := log
+	resources.closers = append(resources.closers, func() { sink.Close(sinkLog) })
+	// Recent messages, reasons of fatal exits and error reports are kept after redaction
+	// of sensitive data, so the redaction wraps them
+	var buffer *ring.Buffer
+	if cfg.LogBufferSize > 0 {
+		buffer = ring.New(log, cfg.LogBufferSize, cfg.LogBufferLevel)
+		log = buffer
+	}
+	if cfg.TerminationLog != "" {
+		log = termination.New(log, termination.Config{
+			Path:    cfg.TerminationLog,
+			Version: version.RELEASE,
+		})
+	}
+	// Errors are reported after redaction of sensitive data too
+	if cfg.SentryDSN != "" {
+		var client *sentry.Client
+		client, err = sentry.NewClient(sentry.Config{
+			DSN:         cfg.SentryDSN,
+			Release:     version.RELEASE,
+			Environment: cfg.SentryEnvironment,
+		})
+		if err != nil {
+			return
+		}
+		// Queued reports are sent on shutdown and fatal exit
+		resources.flushers = append(resources.flushers, client.Flush)
+		log = sentry.New(log, client)
+	}
+	if cfg.LogRedact {
+		if log, err = redaction(log, cfg); err != nil {
+			return
+		}
+	}
+	if cfg.LogSamplingInterval > 0 {
+		log = sampling.New(log, sampling.Config{
+			Interval:   cfg.LogSamplingInterval,
+			First:      cfg.LogSamplingFirst,
+			Thereafter: cfg.LogSamplingThereafter,
+		})
+	}
+
+	// Default logger is used for contexts without a request logger
+	logger.SetDefault(log)
+	// Third-party packages which use log/slog share the same logger
+	stdslog.SetDefault(stdslog.New(slog.NewHandler(log)))
+	// Messages of the standard log package are written with the configured level,
+	// it replaces the output which is set by log/slog above
+	logger.RedirectStdLog(log, cfg.LogStdLevel)
 
 	log.Info("Version:", version.RELEASE)
 	log.Warnf("%s log level is used", logger.LevelDebug.String())
-	log.Infof("Service %s listened on %s:%d", config.SERVICENAME, cfg.LocalHost, cfg.LocalPort)
 
 	// Define handlers
 	h := handlers.New(log, cfg)
+	if buffer != nil {
+		h.SetLogBuffer(buffer)
+	}
+	// Dropped messages of asynchronous outputs are shown by /info
+	for _, w := range []io.Writer{out, errOut} {
+		if writer, ok := w.(*async.Writer); ok {
+			h.AddDroppedLogs(writer.Dropped)
+		}
+	}
 
 	// Register new router
 	r = bit.NewRouter()
@@ -43,12 +145,127 @@ func Setup(cfg *config.Config) (r bit.Router, log logger.Logger, err error) {
 	r.SetupMiddleware(h.Base)
 	r.GET("/", h.Root)
 	r.GET("/healthz", h.Health)
-	r.GET("/readyz", h.Ready)
+	r.GET(ReadinessPath, h.Ready)
 	r.GET("/info", h.Info)
+	r.GET("/loglevel", h.LogLevel)
+	r.PUT("/loglevel", h.SetLogLevel)
+	r.GET("/debug/logs", h.Logs)
 
 	return
 }
 
+// outputs returns stdout and stderr, they are asynchronous if it is configured
+func outputs(cfg *config.Config) (out, err io.Writer) {
+	if !cfg.LogAsync || cfg.LogSink != "" {
+		return os.Stdout, os.Stderr
+	}
+	asyncConfig := async.Config{
+		Size:         cfg.LogAsyncSize,
+		FlushTimeout: cfg.LogAsyncFlushTimeout,
+	}
+	if cfg.LogAsyncDrop {
+		asyncConfig.Policy = async.Drop
+	}
+	return async.New(os.Stdout, asyncConfig), async.New(os.Stderr, asyncConfig)
+}
+
+// output returns logger of the configured sink, stdout and stderr are used by default
+func output(cfg *logger.Config) (log logger.Logger, err error) {
+	switch cfg.Sink {
+	case "":
+		return stdlog.New(cfg), nil
+	case logger.SinkSyslog:
+		log, err = sink.NewSyslog(cfg)
+	case logger.SinkJournald:
+		log, err = sink.NewJournald(cfg)
+	default:
+		return nil, fmt.Errorf("unknown log sink: %s", cfg.Sink)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// setupOutputs contains flushers and closers of outputs of the setup
+type setupOutputs struct {
+	flushers []func() error
+	closers  []func()
+}
+
+var (
+	outputsMutex   sync.Mutex
+	outputsOnce    sync.Once
+	currentOutputs setupOutputs
+)
+
+// replaceOutputs makes outputs of the setup current and releases outputs of the
+// previous setup. Current outputs are flushed by logger.Flush and closed by
+// logger.Exit, the flusher and the exit handler are registered once
+func replaceOutputs(outputs setupOutputs) {
+	outputsOnce.Do(func() {
+		logger.RegisterFlusher(func() error {
+			outputsMutex.Lock()
+			defer outputsMutex.Unlock()
+			return currentOutputs.flush()
+		})
+		logger.RegisterExitHandler(func() {
+			outputsMutex.Lock()
+			defer outputsMutex.Unlock()
+			currentOutputs.close()
+		})
+	})
+	outputsMutex.Lock()
+	previous := currentOutputs
+	currentOutputs = outputs
+	outputsMutex.Unlock()
+	previous.release()
+}
+
+// flush writes queued messages and returns the first error
+func (o setupOutputs) flush() error {
+	var err error
+	for _, flush := range o.flushers {
+		if e := flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// close closes outputs, they are flushed before by logger.Exit
+func (o setupOutputs) close() {
+	for _, closeOutput := range o.closers {
+		closeOutput()
+	}
+}
+
+// release flushes and closes outputs which are not used anymore
+func (o setupOutputs) release() {
+	o.flush()
+	o.close()
+}
+
+// redaction returns logger which masks sensitive data of messages
+func redaction(log logger.Logger, cfg *config.Config) (logger.Logger, error) {
+	rules := redact.DefaultConfig()
+	for _, pattern := range cfg.LogRedactFields {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return log, err
+		}
+		rules.Fields = append(rules.Fields, re)
+	}
+	for _, pattern := range cfg.LogRedactValues {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return log, err
+		}
+		rules.Rules = append(rules.Rules, redact.Rule{Name: pattern, Pattern: re, Replacement: redact.Mask})
+	}
+	return redact.New(log, rules), nil
+}
+
 // Response for undefined methods
 func notFound(c bit.Control) {
 	c.Code(http.StatusNotFound)
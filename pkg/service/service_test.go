@@ -1,15 +1,29 @@
 package service
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	stdlog "log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/takama/bit"
 	// Alternative of the Bit router with the same Router interface
 	// "github.com/takama/k8sapp/pkg/router/httprouter"
 	"github.com/takama/k8sapp/pkg/config"
 	"github.com/takama/k8sapp/pkg/handlers"
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/async"
+	"github.com/takama/k8sapp/pkg/logger/redact"
+	"github.com/takama/k8sapp/pkg/logger/sampling"
+	"github.com/takama/k8sapp/pkg/logger/sentry"
 )
 
 func TestSetup(t *testing.T) {
@@ -46,3 +60,170 @@ func TestSetup(t *testing.T) {
 		t.Error("Expected status:", http.StatusNotFound, "got", trw.Code)
 	}
 }
+
+func TestSetupSampling(t *testing.T) {
+	_, log, err := Setup(&config.Config{LogSamplingInterval: time.Second, LogSamplingFirst: 1})
+	if err != nil {
+		t.Errorf("Fail, got '%s', want '%v'", err, nil)
+	}
+	if _, ok := log.(*sampling.Sampler); !ok {
+		t.Errorf("Expected sampling logger, got %T", log)
+	}
+}
+
+func TestSetupRedaction(t *testing.T) {
+	_, log, err := Setup(&config.Config{LogRedact: true, LogRedactFields: []string{"(?i)^ssn$"}})
+	if err != nil {
+		t.Errorf("Fail, got '%s', want '%v'", err, nil)
+	}
+	if _, ok := log.(*redact.Redactor); !ok {
+		t.Errorf("Expected redaction logger, got %T", log)
+	}
+	_, _, err = Setup(&config.Config{LogRedact: true, LogRedactValues: []string{"("}})
+	if err == nil {
+		t.Error("Expected error of invalid pattern, got", err)
+	}
+}
+
+func TestSetupSentry(t *testing.T) {
+	_, log, err := Setup(&config.Config{SentryDSN: "http://key@127.0.0.1:1/42"})
+	if err != nil {
+		t.Errorf("Fail, got '%s', want '%v'", err, nil)
+	}
+	if _, ok := log.(*sentry.Reporter); !ok {
+		t.Errorf("Expected error reporter, got %T", log)
+	}
+	_, _, err = Setup(&config.Config{SentryDSN: "http://127.0.0.1/42"})
+	if err == nil {
+		t.Error("Expected error of invalid DSN, got", err)
+	}
+}
+
+func TestSetupSentryRedact(t *testing.T) {
+	var mutex sync.Mutex
+	var events []sentry.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Envelope contains the header, the header of the item and the event
+		scanner := bufio.NewScanner(r.Body)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		var event sentry.Event
+		if len(lines) != 3 || json.Unmarshal([]byte(lines[2]), &event) != nil {
+			t.Error("Invalid envelope", lines)
+		}
+		mutex.Lock()
+		events = append(events, event)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	_, log, err := Setup(&config.Config{
+		SentryDSN: strings.Replace(server.URL, "://", "://public@", 1) + "/42",
+		LogRedact: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Setup(new(config.Config))
+	cause := &os.PathError{Op: "open", Path: "/data/users", Err: os.ErrNotExist}
+	log.Errorf("can not find user %d: %v", 1, cause)
+	log.Errorf("can not find user %d: %v", 2, cause)
+	log.Errorf("can not find user %s", "john@example.com")
+	if err := logger.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(events) != 3 {
+		t.Fatal("Expected 3 events, got", events)
+	}
+	if strings.Join(events[0].Fingerprint, " ") != strings.Join(events[1].Fingerprint, " ") {
+		t.Error("Expected grouped errors, got", events[0].Fingerprint, events[1].Fingerprint)
+	}
+	if exception := events[0].Exception.Values[0]; exception.Type != fmt.Sprintf("%T", cause) {
+		t.Error("Expected type of the error", fmt.Sprintf("%T", cause), "got", exception.Type)
+	}
+	if msg := events[2].Message; msg != "can not find user "+redact.Mask {
+		t.Error("Expected masked email, got", msg)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	cfg := &config.Config{LocalHost: "127.0.0.1", LocalPort: 8080, LogStdLevel: logger.LevelWarn}
+	router, log, err := Setup(cfg)
+	if err != nil {
+		t.Errorf("Fail, got '%s', want '%v'", err, nil)
+	}
+	server, err := NewServer(cfg, router, log)
+	if err != nil {
+		t.Fatalf("Fail, got '%s', want '%v'", err, nil)
+	}
+	if server.Addr != "127.0.0.1:8080" {
+		t.Error("Expected address 127.0.0.1:8080, got", server.Addr)
+	}
+	if server.ErrorLog == nil {
+		t.Error("Expected error log of the server, got nil")
+	}
+	if _, ok := stdlog.Writer().(*logger.Writer); !ok {
+		t.Errorf("Expected standard log redirected to the logger, got %T", stdlog.Writer())
+	}
+}
+
+func TestSetupSink(t *testing.T) {
+	if _, _, err := Setup(&config.Config{LogSink: "file"}); err == nil {
+		t.Error("Expected error of unknown sink")
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _, err = Setup(&config.Config{
+		LogSink:        logger.SinkSyslog,
+		LogSinkNetwork: "udp",
+		LogSinkAddress: conn.LocalAddr().String(),
+	})
+	if err != nil {
+		t.Errorf("Fail, got '%s', want '%v'", err, nil)
+	}
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil || !strings.Contains(string(buf[:n]), " k8sapp ") {
+		t.Error("Expected message of the service in syslog, got", string(buf[:n]), err)
+	}
+}
+
+func TestSetupAsync(t *testing.T) {
+	out, errOut := outputs(&config.Config{LogAsync: true, LogAsyncDrop: true})
+	if _, ok := out.(*async.Writer); !ok {
+		t.Errorf("Expected asynchronous output, got %T", out)
+	}
+	if _, ok := errOut.(*async.Writer); !ok {
+		t.Errorf("Expected asynchronous output, got %T", errOut)
+	}
+	if out, _ := outputs(&config.Config{LogAsync: true, LogSink: logger.SinkSyslog}); out != os.Stdout {
+		t.Errorf("Expected stdout for the sink, got %T", out)
+	}
+}
+
+func TestSetupOutputs(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		if _, _, err := Setup(&config.Config{LogAsync: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputsMutex.Lock()
+	flushers := len(currentOutputs.flushers)
+	outputsMutex.Unlock()
+	if flushers != 2 {
+		t.Error("Expected flushers of the last setup only, got", flushers)
+	}
+	if err := logger.Flush(); err != nil {
+		t.Error("Expected flush of asynchronous outputs, got", err)
+	}
+	if _, _, err := Setup(new(config.Config)); err != nil {
+		t.Fatal(err)
+	}
+}
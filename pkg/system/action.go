@@ -0,0 +1,176 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// DefaultTimeout limits actions of signal types without configured timeouts
+const DefaultTimeout = time.Minute
+
+// ActionStats contains outcomes of actions of a signal type
+type ActionStats struct {
+	// Completed actions without errors
+	Completed uint64
+	// Failed actions which returned errors
+	Failed uint64
+	// TimedOut actions which were not completed in the timeout
+	TimedOut uint64
+	// Cancelled actions which were preempted by the shutdown
+	Cancelled uint64
+	// Skipped signals which arrived while an action of the type was queued
+	Skipped uint64
+}
+
+// request is a signal which is queued with its handler
+type request struct {
+	sig     os.Signal
+	handler SignalHandler
+}
+
+// action runs handlers of a signal type one by one,
+// one signal is queued while the handler is running
+type action struct {
+	queue chan request
+
+	mutex sync.Mutex
+	stats ActionStats
+}
+
+// SetTimeout limits duration of actions of the signal type,
+// the actions are not limited if the timeout is zero
+func (s *Signals) SetTimeout(sigType SignalType, timeout time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.timeouts[sigType] = timeout
+}
+
+// Stats returns outcomes of actions of the signal type
+func (s *Signals) Stats(sigType SignalType) ActionStats {
+	s.mutex.RLock()
+	a, ok := s.actions[sigType]
+	s.mutex.RUnlock()
+	if !ok {
+		return ActionStats{}
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.stats
+}
+
+// dispatch queues the signal for the action of its type,
+// the signal is skipped if another one is queued already
+func (s *Signals) dispatch(sigType SignalType, handler SignalHandler, sig os.Signal, logger logger.Logger) {
+	s.mutex.Lock()
+	a, ok := s.actions[sigType]
+	if !ok {
+		a = &action{queue: make(chan request, 1)}
+		s.actions[sigType] = a
+		s.workers.Add(1)
+		go s.work(sigType, a, logger)
+	}
+	s.mutex.Unlock()
+	select {
+	case a.queue <- request{sig: sig, handler: handler}:
+	default:
+		a.count(func(stats *ActionStats) { stats.Skipped++ })
+		logger.Warnf("Signal %s is skipped, %s action is in progress", sig, sigType)
+	}
+}
+
+// work runs queued actions until the shutdown
+func (s *Signals) work(sigType SignalType, a *action, logger logger.Logger) {
+	defer s.workers.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			select {
+			case req := <-a.queue:
+				a.count(func(stats *ActionStats) { stats.Cancelled++ })
+				logger.Warnf("%s action by signal %s is cancelled by the shutdown", sigType, req.sig)
+			default:
+			}
+			return
+		case req := <-a.queue:
+			// The queue could be selected after the shutdown started
+			if s.ctx.Err() != nil {
+				a.count(func(stats *ActionStats) { stats.Cancelled++ })
+				logger.Warnf("%s action by signal %s is cancelled by the shutdown", sigType, req.sig)
+				return
+			}
+			s.execute(sigType, a, req, logger)
+		}
+	}
+}
+
+// execute runs the handler with the timeout of the signal type, its outcome is
+// reported when the timeout expires or the shutdown starts. Handlers which ignore
+// the context can not be preempted, the next action of the type waits until
+// the handler returns. The shutdown does not wait for them
+func (s *Signals) execute(sigType SignalType, a *action, req request, logger logger.Logger) {
+	s.mutex.RLock()
+	timeout, ok := s.timeouts[sigType]
+	s.mutex.RUnlock()
+	if !ok {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := s.ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, timeout)
+	}
+	defer cancel()
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- req.handler(ctx, req.sig)
+	}()
+	select {
+	case err := <-result:
+		s.outcome(sigType, a, err, time.Since(start), timeout, logger)
+	case <-ctx.Done():
+		s.outcome(sigType, a, ctx.Err(), time.Since(start), timeout, logger)
+		select {
+		case <-result:
+		default:
+			logger.Warnf("%s action is still running, it does not handle cancellation", sigType)
+			select {
+			case <-result:
+				logger.Warnf("%s action is returned after %s", sigType, time.Since(start))
+			case <-s.ctx.Done():
+				logger.Warnf("%s action is abandoned by the shutdown", sigType)
+			}
+		}
+	}
+}
+
+// outcome counts and logs the outcome of the action
+func (s *Signals) outcome(sigType SignalType, a *action, err error, took, timeout time.Duration, logger logger.Logger) {
+	switch {
+	case err == nil:
+		a.count(func(stats *ActionStats) { stats.Completed++ })
+		logger.Infof("%s action is completed in %s", sigType, took)
+	case s.ctx.Err() != nil:
+		a.count(func(stats *ActionStats) { stats.Cancelled++ })
+		logger.Warnf("%s action is cancelled by the shutdown after %s", sigType, took)
+	case err == context.DeadlineExceeded:
+		a.count(func(stats *ActionStats) { stats.TimedOut++ })
+		logger.Errorf("%s action is not completed in %s", sigType, timeout)
+	default:
+		a.count(func(stats *ActionStats) { stats.Failed++ })
+		logger.Errorf("%s action failed: %v", sigType, err)
+	}
+}
+
+func (a *action) count(update func(stats *ActionStats)) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	update(&a.stats)
+}
@@ -0,0 +1,195 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+// waitStats waits for the expected outcomes of actions of the type
+func waitStats(t *testing.T, signals *Signals, sigType SignalType, want ActionStats) {
+	deadline := time.Now().Add(time.Second)
+	for signals.Stats(sigType) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("invalid stats of actions:\ngot:  %+v\nwant: %+v", signals.Stats(sigType), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestActionOutcomes(t *testing.T) {
+	log := loggertest.New()
+	signals := NewSignals()
+	defer signals.Stop()
+	signals.SetTimeout(customSignalType, 10*time.Millisecond)
+	failure := errors.New("action failure")
+	signals.dispatch(customSignalType, func(ctx context.Context, sig os.Signal) error {
+		return nil
+	}, testSignal, log)
+	waitStats(t, signals, customSignalType, ActionStats{Completed: 1})
+	signals.dispatch(customSignalType, func(ctx context.Context, sig os.Signal) error {
+		return failure
+	}, testSignal, log)
+	waitStats(t, signals, customSignalType, ActionStats{Completed: 1, Failed: 1})
+	signals.dispatch(customSignalType, func(ctx context.Context, sig os.Signal) error {
+		// The handler ignores the context
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}, testSignal, log)
+	waitStats(t, signals, customSignalType, ActionStats{Completed: 1, Failed: 1, TimedOut: 1})
+	if !log.Contains(logger.LevelError, "action failure") || !log.Contains(logger.LevelError, "not completed in 10ms") {
+		t.Error("Expected logged outcomes of actions, got", log.Entries())
+	}
+}
+
+func TestActionSerialized(t *testing.T) {
+	signals := NewSignals()
+	defer signals.Stop()
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	handler := func(ctx context.Context, sig os.Signal) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	log := loggertest.New()
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	<-started
+	// The second signal is queued, the third one is skipped
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	select {
+	case <-started:
+		t.Error("Expected serialized actions of the type")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	waitStats(t, signals, Reload, ActionStats{Completed: 2, Skipped: 1})
+}
+
+func TestActionTimeoutSerialized(t *testing.T) {
+	log := loggertest.New()
+	signals := NewSignals()
+	defer signals.Stop()
+	signals.SetTimeout(Reload, 10*time.Millisecond)
+	var running, concurrent int32
+	started := make(chan struct{}, 2)
+	handler := func(ctx context.Context, sig os.Signal) error {
+		started <- struct{}{}
+		if n := atomic.AddInt32(&running, 1); n > 1 {
+			atomic.StoreInt32(&concurrent, n)
+		}
+		defer atomic.AddInt32(&running, -1)
+		// The handler ignores the context
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	<-started
+	// The second signal is queued until the first handler returns
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	waitStats(t, signals, Reload, ActionStats{TimedOut: 2, Skipped: 1})
+	for i := 0; i < 100 && !log.Contains(logger.LevelWarn, "RELOAD action is returned"); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&concurrent); n != 0 {
+		t.Error("Expected serialized actions after timeouts, got", n, "concurrent actions")
+	}
+	if !log.Contains(logger.LevelWarn, "RELOAD action is still running") {
+		t.Error("Expected logged action which is still running, got", log.Entries())
+	}
+}
+
+// contextHandling implements ContextOperator, its actions wait for cancellation
+type contextHandling struct {
+	testHandling
+	started chan struct{}
+}
+
+func (ch contextHandling) ReloadContext(ctx context.Context) error {
+	close(ch.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (ch contextHandling) MaintenanceContext(ctx context.Context) error {
+	return ErrNotImplemented
+}
+
+func TestShutdownPreemption(t *testing.T) {
+	log := loggertest.New()
+	signals := NewSignals()
+	signals.SetTimeout(Reload, 0)
+	handling := contextHandling{
+		testHandling: testHandling{ch: make(chan SignalType, 1)},
+		started:      make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- signals.Run(ctx, log, handling)
+	}()
+	signals.dispatch(Reload, builtin(Reload, log, handling), syscall.SIGHUP, log)
+	<-handling.started
+	cancel()
+	if sig := <-handling.ch; sig != Shutdown {
+		t.Error("Expected signal:", Shutdown, "got", sig)
+	}
+	<-result
+	if stats := signals.Stats(Reload); stats != (ActionStats{Cancelled: 1}) {
+		t.Error("Expected reload which is cancelled before the shutdown, got", stats)
+	}
+	if !log.Contains(logger.LevelWarn, "RELOAD action is cancelled by the shutdown") {
+		t.Error("Expected logged cancellation, got", log.Entries())
+	}
+}
+
+func TestShutdownAbandonment(t *testing.T) {
+	log := loggertest.New()
+	signals := NewSignals()
+	codes := make(chan int, 1)
+	signals.exit = func(code int) { codes <- code }
+	signals.SetDeadline(time.Second)
+	signals.SetTimeout(Reload, 10*time.Millisecond)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	// The handler ignores the context
+	handler := func(ctx context.Context, sig os.Signal) error {
+		close(started)
+		<-release
+		return nil
+	}
+	signals.dispatch(Reload, handler, syscall.SIGHUP, log)
+	<-started
+	waitStats(t, signals, Reload, ActionStats{TimedOut: 1})
+	handling := testHandling{ch: make(chan SignalType, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := signals.Run(ctx, log, handling); err != nil {
+		t.Error("Expected graceful shutdown, got", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Error("Expected shutdown without waiting for the action, took", took)
+	}
+	if sig := <-handling.ch; sig != Shutdown {
+		t.Error("Expected signal:", Shutdown, "got", sig)
+	}
+	select {
+	case code := <-codes:
+		t.Error("Expected shutdown without forced exit, got code", code)
+	default:
+	}
+	if !log.Contains(logger.LevelWarn, "RELOAD action is abandoned by the shutdown") {
+		t.Error("Expected logged abandoned action, got", log.Entries())
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import "errors"
+
+// Exit codes of the service by failure classes
+const (
+	// ExitOK is a code of normal termination
+	ExitOK = 0
+	// ExitFailure is a code of fatal messages and other failures
+	ExitFailure = 1
+	// ExitConfig is a code of invalid configuration
+	ExitConfig = 2
+	// ExitListen is a code of the listener which can not be bound or fails
+	ExitListen = 3
+	// ExitShutdownTimeout is a code of graceful shutdown which is not completed in time
+	ExitShutdownTimeout = 4
+	// ExitForced is a code of exit by a repeated shutdown signal
+	ExitForced = 5
+)
+
+// ExitError is an error with the exit code of its failure class
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// NewExitError returns the error with the exit code, nil errors are not wrapped
+func NewExitError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the error, ExitFailure is used
+// for errors without a code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
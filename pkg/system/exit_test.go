@@ -0,0 +1,30 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	failure := errors.New("failure")
+	for _, test := range []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{failure, ExitFailure},
+		{NewExitError(ExitListen, failure), ExitListen},
+		{fmt.Errorf("wrapped: %w", NewExitError(ExitConfig, failure)), ExitConfig},
+	} {
+		if code := ExitCode(test.err); code != test.code {
+			t.Error("Expected exit code", test.code, "got", code, "for", test.err)
+		}
+	}
+	if err := NewExitError(ExitConfig, nil); err != nil {
+		t.Error("Expected nil error, got", err)
+	}
+	if err := NewExitError(ExitConfig, failure); err.Error() != "failure" || !errors.Is(err, failure) {
+		t.Error("Expected wrapped error, got", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2017 Igor Dolzhikov. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
+
+// DumpGoroutines returns handler which writes stacks of all goroutines into the log
+func DumpGoroutines(log logger.Logger) SignalHandler {
+	return func(ctx context.Context, sig os.Signal) error {
+		log.Warnf("Goroutines by signal %s:\n%s", sig, goroutines())
+		return nil
+	}
+}
+
+// ChangeLogLevel returns handler which changes the log level by the delta,
+// a negative delta makes the log more verbose. The level is limited
+// by logger.LevelDebug and logger.LevelFatal
+func ChangeLogLevel(log logger.Logger, delta int) SignalHandler {
+	return func(ctx context.Context, sig os.Signal) error {
+		leveler, ok := log.(logger.Leveler)
+		if !ok {
+			return fmt.Errorf("log level can not be changed for logger %T", log)
+		}
+		level := leveler.Level() + logger.Level(delta)
+		switch {
+		case level < logger.LevelDebug:
+			level = logger.LevelDebug
+		case level > logger.LevelFatal:
+			level = logger.LevelFatal
+		}
+		// Message is logged before the change to be visible in case of a higher level
+		log.Warnf("Log level changed to %s by signal %s", level, sig)
+		leveler.SetLevel(level)
+		return nil
+	}
+}
+
+// goroutines returns stack traces of all goroutines
+func goroutines() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,49 @@
+package system
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/logger"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
+)
+
+func TestDumpGoroutines(t *testing.T) {
+	log := loggertest.New()
+	if err := DumpGoroutines(log)(context.Background(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	if !log.Contains(logger.LevelWarn, "TestDumpGoroutines") {
+		t.Error("Expected stacks of goroutines, got", log.Entries())
+	}
+}
+
+func TestChangeLogLevel(t *testing.T) {
+	log := loggertest.New()
+	log.SetLevel(logger.LevelInfo)
+	verbose := ChangeLogLevel(log, -1)
+	quiet := ChangeLogLevel(log, 1)
+	for _, test := range []struct {
+		handler SignalHandler
+		level   logger.Level
+	}{
+		{verbose, logger.LevelDebug},
+		{verbose, logger.LevelDebug},
+		{quiet, logger.LevelInfo},
+		{quiet, logger.LevelWarn},
+		{quiet, logger.LevelError},
+		{quiet, logger.LevelFatal},
+		{quiet, logger.LevelFatal},
+	} {
+		if err := test.handler(context.Background(), syscall.SIGTTOU); err != nil {
+			t.Fatal(err)
+		}
+		if level := log.Level(); level != test.level {
+			t.Error("Expected log level", test.level, "got", level)
+		}
+	}
+	if err := ChangeLogLevel(struct{ logger.Logger }{log}, 1)(context.Background(), syscall.SIGTTOU); err == nil {
+		t.Error("Expected error of logger without levels, got", err)
+	}
+}
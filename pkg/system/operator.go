@@ -4,7 +4,12 @@
 
 package system
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
 
 // ErrNotImplemented declares error for method that isn't implemented
 var ErrNotImplemented = errors.New("This method is not implemented")
@@ -16,6 +21,14 @@ type Operator interface {
 	Shutdown() error
 }
 
+// ContextOperator defines reload and maintenance with contexts,
+// they are cancelled by timeouts of the actions or by the shutdown.
+// Methods of Operator can not be preempted, the shutdown waits for them
+type ContextOperator interface {
+	ReloadContext(ctx context.Context) error
+	MaintenanceContext(ctx context.Context) error
+}
+
 // Handling implements simplest Operator interface
 type Handling struct{}
 
@@ -29,7 +42,10 @@ func (h Handling) Maintenance() error {
 	return ErrNotImplemented
 }
 
-// Shutdown operation implementation
+// Shutdown operation implementation, it waits for buffered log messages
 func (h Handling) Shutdown() error {
+	if err := logger.Flush(); err != nil {
+		return err
+	}
 	return ErrNotImplemented
 }
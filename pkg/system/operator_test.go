@@ -1,6 +1,11 @@
 package system
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/takama/k8sapp/pkg/logger"
+)
 
 func TestStubHandling(t *testing.T) {
 	handling := new(Handling)
@@ -17,3 +22,19 @@ func TestStubHandling(t *testing.T) {
 		t.Error("Expected error", ErrNotImplemented, "got", err)
 	}
 }
+
+func TestShutdownFlush(t *testing.T) {
+	failure := errors.New("flush failure")
+	flushed := false
+	defer logger.ResetFlushers()
+	logger.RegisterFlusher(func() error {
+		if flushed {
+			return nil
+		}
+		flushed = true
+		return failure
+	})
+	if err := new(Handling).Shutdown(); err != failure || !flushed {
+		t.Error("Expected flush of log messages on shutdown, got", err)
+	}
+}
@@ -5,11 +5,14 @@
 package system
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/takama/k8sapp/pkg/logger"
 )
@@ -24,8 +27,18 @@ const (
 	Reload
 	// Maintenance defines signals for maintenance process
 	Maintenance
+	// Dump defines signals for dump of goroutines into the log
+	Dump
+	// Verbose defines signals which make the log more verbose
+	Verbose
+	// Quiet defines signals which make the log less verbose
+	Quiet
 )
 
+// SignalHandler handles signals of a registered type, the context is cancelled
+// when the timeout of the type expires or the shutdown starts
+type SignalHandler func(ctx context.Context, sig os.Signal) error
+
 func (s SignalType) String() string {
 	switch s {
 	case Shutdown:
@@ -34,6 +47,12 @@ func (s SignalType) String() string {
 		return "RELOAD"
 	case Maintenance:
 		return "MAINTENANCE"
+	case Dump:
+		return "DUMP"
+	case Verbose:
+		return "VERBOSE"
+	case Quiet:
+		return "QUIET"
 	default:
 		return strconv.Itoa(int(s))
 	}
@@ -44,11 +63,31 @@ type Signals struct {
 	mutex sync.RWMutex
 
 	interrupt chan os.Signal
-	quit      chan struct{}
 
-	shutdown    []os.Signal
-	reload      []os.Signal
-	maintenance []os.Signal
+	// root context of the service
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// deadline of the shutdown, exit terminates the process when it expires
+	// or a shutdown signal is repeated, done stops waiting for them
+	deadline time.Duration
+	exit     func(code int)
+	done     chan struct{}
+	finish   sync.Once
+
+	// stopped signals are not subscribed anymore
+	stopped bool
+
+	// signals and handlers by types, handlers of Reload and Maintenance
+	// call the operator if they are not registered
+	signals  map[SignalType][]os.Signal
+	handlers map[SignalType]SignalHandler
+
+	// actions run handlers of signal types in background,
+	// they are limited by timeouts of their types
+	actions  map[SignalType]*action
+	timeouts map[SignalType]time.Duration
+	workers  sync.WaitGroup
 }
 
 // NewSignals creates default signals
@@ -58,32 +97,80 @@ func NewSignals() *Signals {
 		// We must use a buffered channel or risk missing the signal
 		// if we're not ready to receive when the signal is sent.
 		interrupt: make(chan os.Signal, 1),
-		quit:      make(chan struct{}, 1),
+		exit:      forceExit,
+		done:      make(chan struct{}),
 
-		shutdown:    []os.Signal{syscall.SIGINT, syscall.SIGTERM},
-		reload:      []os.Signal{syscall.SIGHUP},
-		maintenance: []os.Signal{syscall.SIGUSR1},
+		signals: map[SignalType][]os.Signal{
+			Shutdown:    {syscall.SIGINT, syscall.SIGTERM},
+			Reload:      {syscall.SIGHUP},
+			Maintenance: {syscall.SIGUSR1},
+		},
+		handlers: make(map[SignalType]SignalHandler),
+		actions:  make(map[SignalType]*action),
+		timeouts: make(map[SignalType]time.Duration),
 	}
-	signal.Notify(signals.interrupt)
+	signals.ctx, signals.cancel = context.WithCancel(context.Background())
+	signals.subscribe()
 	return signals
 }
 
+// Stop releases the subscription to signals, they are handled by default
+// or by other subscribers, waiting for a repeated shutdown signal
+// or the shutdown deadline is stopped too
+func (s *Signals) Stop() {
+	s.mutex.Lock()
+	s.stopped = true
+	signal.Stop(s.interrupt)
+	s.mutex.Unlock()
+	s.finish.Do(func() { close(s.done) })
+}
+
+// subscribe notifies about registered signals only, it must be called
+// with locked mutex. Removed signals are unsubscribed by a new subscription
+func (s *Signals) subscribe() {
+	if s.stopped {
+		return
+	}
+	var signals []os.Signal
+	for _, list := range s.signals {
+		signals = append(signals, list...)
+	}
+	if len(signals) == 0 {
+		signal.Stop(s.interrupt)
+		return
+	}
+	// Signals are held by a temporary subscription to not be handled by default
+	// while the subscription is replaced
+	hold := make(chan os.Signal, 1)
+	signal.Notify(hold, signals...)
+	signal.Stop(s.interrupt)
+	signal.Notify(s.interrupt, signals...)
+	signal.Stop(hold)
+	select {
+	case sig := <-hold:
+		select {
+		case s.interrupt <- sig:
+		default:
+		}
+	default:
+	}
+}
+
+// SetDeadline sets time limit of the shutdown, the process exits
+// with ExitShutdownTimeout and a goroutine dump in the log when it expires.
+// The shutdown is not limited if the deadline is zero
+func (s *Signals) SetDeadline(deadline time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deadline = deadline
+}
+
 // Get signals by specified type
 func (s *Signals) Get(sigType SignalType) (signals []os.Signal) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	switch sigType {
-	case Shutdown:
-		signals = make([]os.Signal, len(s.shutdown))
-		copy(signals, s.shutdown)
-	case Reload:
-		signals = make([]os.Signal, len(s.reload))
-		copy(signals, s.reload)
-	case Maintenance:
-		signals = make([]os.Signal, len(s.maintenance))
-		copy(signals, s.maintenance)
-	}
-
+	signals = make([]os.Signal, len(s.signals[sigType]))
+	copy(signals, s.signals[sigType])
 	return
 }
 
@@ -91,13 +178,9 @@ func (s *Signals) Get(sigType SignalType) (signals []os.Signal) {
 func (s *Signals) Add(sig os.Signal, sigType SignalType) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	switch sigType {
-	case Shutdown:
-		s.shutdown = append(s.shutdown, sig)
-	case Reload:
-		s.reload = append(s.reload, sig)
-	case Maintenance:
-		s.maintenance = append(s.maintenance, sig)
+	s.signals[sigType] = append(s.signals[sigType], sig)
+	if !s.stopped {
+		signal.Notify(s.interrupt, sig)
 	}
 }
 
@@ -105,51 +188,192 @@ func (s *Signals) Add(sig os.Signal, sigType SignalType) {
 func (s *Signals) Remove(sig os.Signal, sigType SignalType) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	switch sigType {
-	case Shutdown:
-		s.shutdown = removeSignal(sig, s.shutdown)
-	case Reload:
-		s.reload = removeSignal(sig, s.reload)
-	case Maintenance:
-		s.maintenance = removeSignal(sig, s.maintenance)
+	s.signals[sigType] = removeSignal(sig, s.signals[sigType])
+	s.subscribe()
+}
+
+// Handle registers the handler of the signal type and appends its signals,
+// it replaces the operator for Reload and Maintenance types.
+// Shutdown signals are handled by the root context and the operator only
+func (s *Signals) Handle(sigType SignalType, handler SignalHandler, signals ...os.Signal) {
+	s.mutex.Lock()
+	s.handlers[sigType] = handler
+	s.mutex.Unlock()
+	for _, sig := range signals {
+		s.Add(sig, sigType)
 	}
 }
 
-// Wait needs to catch signal and do graceful shutdown
+// Context returns the root context of the service,
+// it is cancelled when the shutdown starts
+func (s *Signals) Context() context.Context {
+	return s.ctx
+}
+
+// Wait needs to catch signal and do graceful shutdown,
+// it returns error of the shutdown except of ErrNotImplemented
 func (s *Signals) Wait(logger logger.Logger, operator Operator) error {
+	return s.Run(context.Background(), logger, operator)
+}
+
+// Run catches signals until a shutdown signal arrives or the context is done,
+// then the root context is cancelled and the operator shuts down the service.
+// The operator could be nil if the service is stopped by the root context.
+// It returns error of the shutdown except of ErrNotImplemented
+func (s *Signals) Run(ctx context.Context, logger logger.Logger, operator Operator) error {
 	for {
 		select {
-		case <-s.quit:
-			logger.Info("Gracefully closed")
-			return nil
+		case <-ctx.Done():
+			logger.Info("Service was terminated by the context")
+			return s.terminate(logger, operator)
 		case sig := <-s.interrupt:
-			s.mutex.RLock()
 			logger.Infof("Got signal: %s", sig)
+			sigType, handler, ok := s.handler(sig, logger, operator)
 			switch {
-			case isSignalAvailable(sig, s.maintenance):
-				s.mutex.RUnlock()
-				logger.Info("Maintenance request")
-				err := operator.Maintenance()
-				if err != nil {
-					logger.Error(err)
-				}
-			case isSignalAvailable(sig, s.reload):
-				s.mutex.RUnlock()
-				logger.Info("Reloading configuration...")
-				err := operator.Reload()
-				if err != nil {
-					logger.Error(err)
-				}
-			case isSignalAvailable(sig, s.shutdown):
-				s.mutex.RUnlock()
+			case !ok:
+			case sigType == Shutdown:
 				logger.Info("Service was terminated by system signal")
-				err := operator.Shutdown()
-				if err != nil {
-					logger.Error(err)
-				}
-				s.quit <- struct{}{}
+				return s.terminate(logger, operator)
+			case handler != nil:
+				s.dispatch(sigType, handler, sig, logger)
+			}
+		}
+	}
+}
+
+// handler returns type of the signal and its handler, the operator handles
+// Reload and Maintenance types without registered handlers
+func (s *Signals) handler(
+	sig os.Signal, logger logger.Logger, operator Operator,
+) (SignalType, SignalHandler, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	types := make([]SignalType, 0, len(s.signals))
+	for sigType := range s.signals {
+		types = append(types, sigType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	for _, sigType := range types {
+		if !isSignalAvailable(sig, s.signals[sigType]) {
+			continue
+		}
+		if handler, ok := s.handlers[sigType]; ok {
+			return sigType, handler, true
+		}
+		return sigType, builtin(sigType, logger, operator), true
+	}
+	return 0, nil, false
+}
+
+// builtin returns handler of Reload and Maintenance types by the operator,
+// the context is passed to operators which implement ContextOperator
+func builtin(sigType SignalType, logger logger.Logger, operator Operator) SignalHandler {
+	contextOperator, _ := operator.(ContextOperator)
+	switch sigType {
+	case Reload:
+		return func(ctx context.Context, sig os.Signal) error {
+			logger.Info("Reloading configuration...")
+			switch {
+			case contextOperator != nil:
+				return contextOperator.ReloadContext(ctx)
+			case operator != nil:
+				return operator.Reload()
 			}
+			return nil
 		}
+	case Maintenance:
+		return func(ctx context.Context, sig os.Signal) error {
+			logger.Info("Maintenance request")
+			switch {
+			case contextOperator != nil:
+				return contextOperator.MaintenanceContext(ctx)
+			case operator != nil:
+				return operator.Maintenance()
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// terminate cancels the root context and actions in progress, then calls the operator,
+// the process is terminated if the shutdown is not completed in the deadline
+// or by a repeated shutdown signal
+func (s *Signals) terminate(logger logger.Logger, operator Operator) error {
+	// Actions in progress are cancelled by the root context
+	s.cancel()
+	go s.watch(logger)
+	s.workers.Wait()
+	if operator == nil {
+		// The shutdown is continued by users of the root context
+		return nil
+	}
+	defer s.finish.Do(func() { close(s.done) })
+	err := operator.Shutdown()
+	if err != nil {
+		logger.Error(err)
+		if err == ErrNotImplemented {
+			err = nil
+		}
+	}
+	logger.Info("Gracefully closed")
+	return err
+}
+
+// watch forces exit of the process during the shutdown
+func (s *Signals) watch(logger logger.Logger) {
+	s.mutex.RLock()
+	deadline := s.deadline
+	s.mutex.RUnlock()
+	var expired <-chan time.Time
+	if deadline > 0 {
+		timer := time.NewTimer(deadline)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case sig := <-s.interrupt:
+			s.mutex.RLock()
+			repeated := isSignalAvailable(sig, s.signals[Shutdown])
+			s.mutex.RUnlock()
+			// Other signals are ignored during the shutdown
+			if repeated {
+				logger.Errorf("Got signal %s during the shutdown, forced exit", sig)
+				s.exit(ExitForced)
+				return
+			}
+		case <-expired:
+			logger.Errorf("Shutdown deadline %s exceeded, goroutines:\n%s", deadline, goroutines())
+			s.exit(ExitShutdownTimeout)
+			return
+		}
+	}
+}
+
+// ForcedExitTimeout limits flush of buffered log messages before the forced exit
+const ForcedExitTimeout = time.Second
+
+// forceExit terminates the process without waiting for slow outputs
+// of buffered log messages
+func forceExit(code int) {
+	flush(ForcedExitTimeout)
+	os.Exit(code)
+}
+
+// flush writes buffered log messages, it returns after the timeout
+// even if they are not written
+func flush(timeout time.Duration) {
+	flushed := make(chan struct{})
+	go func() {
+		logger.Flush()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(timeout):
 	}
 }
 
@@ -1,12 +1,16 @@
 package system
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/signal"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/takama/k8sapp/pkg/logger"
-	"github.com/takama/k8sapp/pkg/logger/standard"
+	"github.com/takama/k8sapp/pkg/logger/loggertest"
 )
 
 const (
@@ -40,9 +44,7 @@ func (th testHandling) Shutdown() error {
 
 func TestSignals(t *testing.T) {
 	// Setup logger
-	log := standard.New(&logger.Config{
-		Level: logger.LevelDebug,
-	})
+	log := loggertest.New()
 	pid := os.Getpid()
 	proc, err := os.FindProcess(pid)
 	if err != nil {
@@ -76,6 +78,46 @@ func TestSignals(t *testing.T) {
 	signals.Add(testSignal, Shutdown)
 	sendSignal(t, handling.ch, proc, Shutdown)
 	signals.Remove(testSignal, Shutdown)
+
+	for _, message := range []string{
+		"Reloading configuration...",
+		"Maintenance request",
+		"Service was terminated by system signal",
+	} {
+		if !log.Contains(logger.LevelInfo, message) {
+			t.Error("Expected message:", message)
+		}
+	}
+}
+
+// failedHandling returns error of the shutdown
+type failedHandling struct {
+	testHandling
+}
+
+func (fh failedHandling) Shutdown() error {
+	fh.ch <- Shutdown
+	return errShutdown
+}
+
+var errShutdown = errors.New("shutdown error")
+
+func TestWaitShutdownError(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Finding process:", err)
+	}
+	signals := NewSignals()
+	signals.Add(testSignal, Shutdown)
+	handling := failedHandling{testHandling{ch: make(chan SignalType, 1)}}
+	result := make(chan error, 1)
+	go func() {
+		result <- signals.Wait(loggertest.New(), handling)
+	}()
+	sendSignal(t, handling.ch, proc, Shutdown)
+	if err := <-result; err != errShutdown {
+		t.Error("Expected", errShutdown, "got", err)
+	}
 }
 
 func sendSignal(t *testing.T, ch <-chan SignalType, proc *os.Process, signal SignalType) {
@@ -110,6 +152,11 @@ func TestSignalStringer(t *testing.T) {
 	if s.String() != "MAINTENANCE" {
 		t.Error("Expected signal type MAINTENANCE, got", s.String())
 	}
+	for sigType, name := range map[SignalType]string{Dump: "DUMP", Verbose: "VERBOSE", Quiet: "QUIET"} {
+		if sigType.String() != name {
+			t.Error("Expected signal type", name, "got", sigType.String())
+		}
+	}
 	s = customSignalType
 	if s.String() != customSignalTypeString {
 		t.Error("Expected signal type ", customSignalTypeString, "got", s.String())
@@ -118,9 +165,223 @@ func TestSignalStringer(t *testing.T) {
 
 func TestRemoveNotExistingSignal(t *testing.T) {
 	s := NewSignals()
-	count := len(s.maintenance)
+	count := len(s.signals[Maintenance])
 	s.Remove(syscall.SIGUSR2, Maintenance)
-	if len(s.maintenance) != count {
-		t.Error("Expected count of signals", count, "got", len(s.maintenance))
+	if len(s.signals[Maintenance]) != count {
+		t.Error("Expected count of signals", count, "got", len(s.signals[Maintenance]))
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	signals := NewSignals()
+	ctx, cancel := context.WithCancel(context.Background())
+	handling := &testHandling{ch: make(chan SignalType, 1)}
+	result := make(chan error, 1)
+	go func() {
+		result <- signals.Run(ctx, loggertest.New(), handling)
+	}()
+	select {
+	case <-signals.Context().Done():
+		t.Fatal("Expected root context which is not cancelled before shutdown")
+	default:
+	}
+	cancel()
+	if sig := <-handling.ch; sig != Shutdown {
+		t.Error("Expected signal:", Shutdown, "got", sig)
+	}
+	if err := <-result; err != nil {
+		t.Error("Expected ignored", ErrNotImplemented, "got", err)
+	}
+	if err := signals.Context().Err(); err != context.Canceled {
+		t.Error("Expected cancelled root context, got", err)
+	}
+}
+
+func TestRunWithoutOperator(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Finding process:", err)
+	}
+	signals := NewSignals()
+	signals.Add(testSignal, Shutdown)
+	result := make(chan error, 1)
+	go func() {
+		result <- signals.Run(context.Background(), loggertest.New(), nil)
+	}()
+	if err := proc.Signal(testSignal); err != nil {
+		t.Fatal("Sending signal:", err)
+	}
+	<-signals.Context().Done()
+	if err := <-result; err != nil {
+		t.Error("Expected shutdown without errors, got", err)
+	}
+	signals.Remove(testSignal, Shutdown)
+}
+
+// blockedHandling does not complete the shutdown until it is released
+type blockedHandling struct {
+	Handling
+	release chan struct{}
+}
+
+func (bh blockedHandling) Shutdown() error {
+	<-bh.release
+	return nil
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	log := loggertest.New()
+	signals := NewSignals()
+	codes := make(chan int, 1)
+	signals.exit = func(code int) { codes <- code }
+	signals.SetDeadline(10 * time.Millisecond)
+	handling := blockedHandling{release: make(chan struct{})}
+	defer close(handling.release)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	go signals.Run(ctx, log, handling)
+	if code := <-codes; code != ExitShutdownTimeout {
+		t.Error("Expected exit code", ExitShutdownTimeout, "got", code)
+	}
+	if !log.Contains(logger.LevelError, "goroutine ") || !log.Contains(logger.LevelError, "TestShutdownDeadline") {
+		t.Error("Expected goroutine dump, got", log.Entries())
+	}
+}
+
+func TestForcedExit(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Finding process:", err)
+	}
+	signals := NewSignals()
+	signals.Add(testSignal, Shutdown)
+	defer signals.Remove(testSignal, Shutdown)
+	codes := make(chan int, 1)
+	signals.exit = func(code int) { codes <- code }
+	handling := blockedHandling{release: make(chan struct{})}
+	defer close(handling.release)
+	go signals.Run(context.Background(), loggertest.New(), handling)
+	if err := proc.Signal(testSignal); err != nil {
+		t.Fatal("Sending signal:", err)
+	}
+	<-signals.Context().Done()
+	if err := proc.Signal(testSignal); err != nil {
+		t.Fatal("Sending signal:", err)
+	}
+	if code := <-codes; code != ExitForced {
+		t.Error("Expected exit code", ExitForced, "got", code)
+	}
+}
+
+func TestForcedFlush(t *testing.T) {
+	defer logger.ResetFlushers()
+	release := make(chan struct{})
+	defer close(release)
+	// Slow output of buffered messages
+	logger.RegisterFlusher(func() error {
+		<-release
+		return nil
+	})
+	start := time.Now()
+	flush(10 * time.Millisecond)
+	if took := time.Since(start); took > time.Second {
+		t.Error("Expected flush limited by the timeout, took", took)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Finding process:", err)
+	}
+	// The test signal must not terminate the process while it is not subscribed
+	other := make(chan os.Signal, 1)
+	signal.Notify(other, testSignal)
+	defer signal.Stop(other)
+	signals := NewSignals()
+	defer signals.Stop()
+	received := func() bool {
+		if err := proc.Signal(testSignal); err != nil {
+			t.Fatal("Sending signal:", err)
+		}
+		<-other
+		select {
+		case <-signals.interrupt:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}
+	if received() {
+		t.Error("Expected signal which is not subscribed")
+	}
+	signals.Add(testSignal, Reload)
+	if !received() {
+		t.Error("Expected signal which is subscribed by Add")
+	}
+	signals.Remove(testSignal, Reload)
+	if received() {
+		t.Error("Expected signal which is unsubscribed by Remove")
+	}
+	signals.Add(testSignal, Maintenance)
+	signals.Stop()
+	if received() {
+		t.Error("Expected signal which is unsubscribed by Stop")
+	}
+	signals.Add(testSignal, Shutdown)
+	if received() {
+		t.Error("Expected no subscription after Stop")
+	}
+}
+
+func TestHandle(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Finding process:", err)
+	}
+	signals := NewSignals()
+	defer signals.Stop()
+	handled := make(chan SignalType, 1)
+	signals.Handle(customSignalType, func(ctx context.Context, sig os.Signal) error {
+		handled <- customSignalType
+		return errShutdown
+	}, testSignal)
+	// Registered handler replaces the operator
+	signals.Handle(Reload, func(ctx context.Context, sig os.Signal) error {
+		handled <- Reload
+		return nil
+	})
+	log := loggertest.New()
+	handling := &testHandling{ch: make(chan SignalType, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- signals.Run(ctx, log, handling)
+	}()
+	if err := proc.Signal(testSignal); err != nil {
+		t.Fatal("Sending signal:", err)
+	}
+	if sigType := <-handled; sigType != customSignalType {
+		t.Error("Expected signal type", customSignalType, "got", sigType)
+	}
+	signals.Remove(testSignal, customSignalType)
+	signals.Add(testSignal, Reload)
+	if err := proc.Signal(testSignal); err != nil {
+		t.Fatal("Sending signal:", err)
+	}
+	if sigType := <-handled; sigType != Reload {
+		t.Error("Expected signal type", Reload, "got", sigType)
+	}
+	signals.Remove(testSignal, Reload)
+	cancel()
+	<-handling.ch
+	<-result
+	if !log.Contains(logger.LevelError, errShutdown.Error()) {
+		t.Error("Expected logged error of the handler, got", log.Entries())
+	}
+	select {
+	case sigType := <-handling.ch:
+		t.Error("Expected reload without the operator, got", sigType)
+	default:
 	}
 }